@@ -0,0 +1,92 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// defaultBigFloatPrecision is the precision in bits of the big.Float values if none is configured.
+const defaultBigFloatPrecision = 64
+
+// convertAssignBigInt copies to d the integer in src, written in the number
+// and integer formats of the field.
+func convertAssignBigInt(d *big.Int, src string, fc *FieldContext) error {
+	format, err := numberFormat(fc)
+	if err != nil {
+		return err
+	}
+	num, percent, err := normalizeNumber(src, format)
+	if err != nil {
+		return err
+	}
+	if percent {
+		return fmt.Errorf("percentage %q can't be stored into type *big.Int", src)
+	}
+	i, err := parseBigInteger(num, intFormat(fc))
+	if err != nil {
+		return err
+	}
+	d.Set(i)
+	return nil
+}
+
+// convertAssignBigFloat copies to d the number in src, written in the number format
+// of the field. The precision and the rounding mode are taken from the configuration
+// or from the prec tag option.
+func convertAssignBigFloat(d *big.Float, src string, fc *FieldContext) error {
+	num, percent, err := normalizedDecimal(src, fc)
+	if err != nil {
+		return err
+	}
+
+	prec := fc.Config.BigFloatPrecision
+	if p, ok := fc.Options.Get("prec"); ok {
+		v, err := strconv.ParseUint(p, 10, 32)
+		if err != nil || v == 0 || v > big.MaxPrec {
+			return fmt.Errorf("%w: option prec requires a precision in bits, got %q", ErrInvalidTag, p)
+		}
+		prec = uint(v)
+	}
+	if prec == 0 {
+		prec = defaultBigFloatPrecision
+	}
+
+	f, _, err := big.ParseFloat(num, 10, prec, fc.Config.BigFloatRounding)
+	if err != nil {
+		return fmt.Errorf("invalid number %q: %w", src, err)
+	}
+	if percent {
+		f.Quo(f, big.NewFloat(100))
+	}
+	d.SetPrec(prec).SetMode(fc.Config.BigFloatRounding).Set(f)
+	return nil
+}
+
+// convertAssignBigRat copies to d the exact value of the number in src, written in
+// the number format of the field. Fractions like 1/3 are accepted as well.
+func convertAssignBigRat(d *big.Rat, src string, fc *FieldContext) error {
+	num, percent, err := normalizedDecimal(src, fc)
+	if err != nil {
+		return err
+	}
+	r, ok := new(big.Rat).SetString(num)
+	if !ok {
+		return fmt.Errorf("invalid number %q", src)
+	}
+	if percent {
+		r.Quo(r, big.NewRat(100, 1))
+	}
+	d.Set(r)
+	return nil
+}
+
+// normalizedDecimal rewrites the number in src, written in the number format of the field,
+// in the syntax of the strconv package. The boolean is true if the number is a percentage.
+func normalizedDecimal(src string, fc *FieldContext) (string, bool, error) {
+	format, err := numberFormat(fc)
+	if err != nil {
+		return "", false, err
+	}
+	return normalizeNumber(src, format)
+}
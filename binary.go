@@ -0,0 +1,94 @@
+package csvdecoder
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// BinaryEncoding is the encoding of the binary values in the CSV fields.
+// It is used when decoding into []byte and [N]byte types.
+type BinaryEncoding int
+
+const (
+	BinaryRaw       BinaryEncoding = iota // the bytes of the field as is. [N]byte values are decoded like other arrays.
+	BinaryBase64                          // the standard base64 encoding, with or without padding
+	BinaryBase64URL                       // the URL safe base64 encoding, with or without padding
+	BinaryHex                             // the hexadecimal encoding
+)
+
+// binaryEncoding returns the binary encoding of the field. The base64, base64url and
+// hex tag options override the encoding of the configuration.
+func binaryEncoding(fc *FieldContext) BinaryEncoding {
+	switch {
+	case fc.Options.Has("base64"):
+		return BinaryBase64
+	case fc.Options.Has("base64url"):
+		return BinaryBase64URL
+	case fc.Options.Has("hex"):
+		return BinaryHex
+	}
+	return fc.Config.BinaryEncoding
+}
+
+// decodeBinary returns the bytes encoded in src.
+func decodeBinary(src string, encoding BinaryEncoding) ([]byte, error) {
+	var b []byte
+	var err error
+	// a padded value must carry the exact padding of its length
+	padded := strings.HasSuffix(src, "=")
+	switch encoding {
+	case BinaryBase64:
+		if padded {
+			b, err = base64.StdEncoding.DecodeString(src)
+		} else {
+			b, err = base64.RawStdEncoding.DecodeString(src)
+		}
+	case BinaryBase64URL:
+		if padded {
+			b, err = base64.URLEncoding.DecodeString(src)
+		} else {
+			b, err = base64.RawURLEncoding.DecodeString(src)
+		}
+	case BinaryHex:
+		b, err = hex.DecodeString(src)
+	default:
+		return []byte(src), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value %q: %w", encoding, src, err)
+	}
+	return b, nil
+}
+
+// convertAssignBinary copies to dv, a byte slice or array, the bytes encoded in src.
+// The number of bytes must match the length of an array.
+func convertAssignBinary(dv reflect.Value, src string, encoding BinaryEncoding) error {
+	b, err := decodeBinary(src, encoding)
+	if err != nil {
+		return err
+	}
+	if dv.Kind() == reflect.Array {
+		if len(b) != dv.Len() {
+			return fmt.Errorf("got %d bytes for type %s of length %d", len(b), dv.Type(), dv.Len())
+		}
+		reflect.Copy(dv, reflect.ValueOf(b))
+		return nil
+	}
+	dv.Set(reflect.ValueOf(b).Convert(dv.Type()))
+	return nil
+}
+
+func (e BinaryEncoding) String() string {
+	switch e {
+	case BinaryBase64:
+		return "base64"
+	case BinaryBase64URL:
+		return "base64url"
+	case BinaryHex:
+		return "hex"
+	}
+	return "raw"
+}
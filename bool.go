@@ -0,0 +1,85 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BoolFormat describes the tokens of the boolean values in the CSV fields,
+// e.g. yes and no. It is used when decoding into the bool types.
+type BoolFormat struct {
+	True            []string // the tokens of the true values
+	False           []string // the tokens of the false values
+	CaseInsensitive bool     // if set to true, the tokens are matched regardless of the case
+	Strict          bool     // if set to true, a value matching no token is an error. Otherwise it is parsed by strconv.ParseBool.
+}
+
+// boolFormat returns the bool format of the field, or nil if the values must be
+// parsed by strconv.ParseBool. The true, false, ignorecase and strict tag options
+// override the format of the configuration. The ignorecase option also applies to the
+// values of the oneof option.
+func boolFormat(fc *FieldContext) *BoolFormat {
+	format := fc.Config.BoolFormat
+	trueTokens, hasTrue := fc.Options.Get("true")
+	falseTokens, hasFalse := fc.Options.Get("false")
+	ignoreCase, strict := fc.Options.Has("ignorecase"), fc.Options.Has("strict")
+	if !hasTrue && !hasFalse && !ignoreCase && !strict {
+		return format
+	}
+
+	f := BoolFormat{}
+	if format != nil {
+		f = *format
+	}
+	if hasTrue {
+		f.True = strings.Split(trueTokens, "|")
+	}
+	if hasFalse {
+		f.False = strings.Split(falseTokens, "|")
+	}
+	f.CaseInsensitive = f.CaseInsensitive || ignoreCase
+	f.Strict = f.Strict || strict
+	return &f
+}
+
+// checkTokens returns an error if a token of the format is empty. The empty
+// fields are never decoded, so an empty token would never match.
+func (f *BoolFormat) checkTokens() error {
+	for _, tokens := range [][]string{f.True, f.False} {
+		for _, token := range tokens {
+			if token == "" {
+				return fmt.Errorf("empty bool token: the empty fields are left untouched")
+			}
+		}
+	}
+	return nil
+}
+
+// parseBool returns the boolean value of src in the given format.
+func parseBool(src string, format *BoolFormat) (bool, error) {
+	if format == nil {
+		return strconv.ParseBool(src)
+	}
+	if format.match(src, format.True) {
+		return true, nil
+	}
+	if format.match(src, format.False) {
+		return false, nil
+	}
+	if format.Strict {
+		return false, fmt.Errorf("invalid boolean %q: expected one of %s for true or %s for false",
+			src, strings.Join(format.True, ", "), strings.Join(format.False, ", "))
+	}
+	return strconv.ParseBool(src)
+}
+
+// match reports whether src is one of the tokens.
+func (f *BoolFormat) match(src string, tokens []string) bool {
+	for _, token := range tokens {
+		if src == token || (f.CaseInsensitive && strings.EqualFold(src, token)) {
+			return true
+		}
+	}
+	return false
+}
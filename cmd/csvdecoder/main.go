@@ -0,0 +1,180 @@
+// Command csvdecoder provides tools for the CSV files decoded with the csvdecoder package.
+//
+// Usage:
+//
+//	csvdecoder gen-struct [flags] [file]
+//	csvdecoder profile [flags] [file]
+//
+// The gen-struct command reads a sample of a CSV file with a header line, infers the
+// types of its columns and writes a Go struct decoding its rows with Decoder.Decode.
+//
+// The profile command reads a CSV file with a header line and writes the profile of its
+// columns as a text table, or in JSON with the -json flag.
+//
+// The file is read from the standard input if not given.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/stefantds/csvdecoder"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	var err error
+	switch os.Args[1] {
+	case "gen-struct":
+		err = genStruct(os.Args[2:])
+	case "profile":
+		err = profile(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "csvdecoder:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: csvdecoder gen-struct [flags] [file]")
+	fmt.Fprintln(os.Stderr, "       csvdecoder profile [flags] [file]")
+	os.Exit(2)
+}
+
+// input returns the file named by the arguments, or the standard input if there is none.
+func input(fs *flag.FlagSet) (io.ReadCloser, error) {
+	switch fs.NArg() {
+	case 0:
+		return io.NopCloser(os.Stdin), nil
+	case 1:
+		return os.Open(fs.Arg(0))
+	}
+	fs.Usage()
+	os.Exit(2)
+	return nil, nil
+}
+
+// separator returns the rune of the -comma flag.
+func separator(comma string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(comma)
+	if size == 0 || size != len(comma) || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid separator %q", comma)
+	}
+	return r, nil
+}
+
+// missingValues returns the missing values listed in the -null flag, in addition to the empty string.
+func missingValues(null string) []string {
+	if null == "" {
+		return nil
+	}
+	return append([]string{""}, strings.Split(null, ",")...)
+}
+
+func genStruct(args []string) error {
+	fs := flag.NewFlagSet("gen-struct", flag.ExitOnError)
+	typeName := fs.String("type", "Record", "the name of the struct type")
+	pkg := fs.String("package", "main", "the name of the package of the generated file")
+	output := fs.String("o", "", "the generated file. Default is the standard output")
+	comma := fs.String("comma", ",", "the character separating the values")
+	sample := fs.Int("sample", 1000, "the number of rows sampled to infer the types")
+	confidence := fs.Float64("confidence", 1, "the minimum ratio of the values conforming to a type")
+	null := fs.String("null", "", "the values standing for missing values, separated by commas, e.g. NA,-")
+	schemaFile := fs.String("schema", "", "a Table Schema JSON file describing the columns, used instead of inferring them")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: csvdecoder gen-struct [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	r, err := separator(*comma)
+	if err != nil {
+		return err
+	}
+
+	var schema *csvdecoder.Schema
+	if *schemaFile != "" {
+		f, err := os.Open(*schemaFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if schema, err = csvdecoder.ReadSchema(f); err != nil {
+			return err
+		}
+	} else {
+		in, err := input(fs)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+		opts := csvdecoder.InferOptions{
+			Config:        csvdecoder.Config{Comma: r, IgnoreHeaders: true},
+			SampleSize:    *sample,
+			MinConfidence: *confidence,
+			MissingValues: missingValues(*null),
+		}
+		if schema, err = csvdecoder.Infer(in, opts); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := csvdecoder.GenerateStruct(&buf, schema, csvdecoder.GenerateOptions{Package: *pkg, TypeName: *typeName}); err != nil {
+		return err
+	}
+	if *output == "" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(*output, buf.Bytes(), 0o644)
+}
+
+func profile(args []string) error {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	comma := fs.String("comma", ",", "the character separating the values")
+	null := fs.String("null", "", "the values standing for missing values, separated by commas, e.g. NA,-")
+	distinct := fs.Int("distinct", 10000, "the number of distinct values counted exactly per column")
+	top := fs.Int("top", 10, "the number of most frequent values reported per column")
+	asJSON := fs.Bool("json", false, "write the profile in JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: csvdecoder profile [flags] [file]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	r, err := separator(*comma)
+	if err != nil {
+		return err
+	}
+	in, err := input(fs)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	d, err := csvdecoder.NewWithConfig(in, csvdecoder.Config{Comma: r, IgnoreHeaders: true})
+	if err != nil {
+		return err
+	}
+	p, err := d.Profile(csvdecoder.ProfileOptions{
+		MissingValues: missingValues(*null),
+		DistinctLimit: *distinct,
+		TopValues:     *top,
+	})
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return p.Write(os.Stdout)
+	}
+	return p.WriteText(os.Stdout)
+}
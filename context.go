@@ -0,0 +1,41 @@
+package csvdecoder
+
+// FieldContext describes the CSV field being decoded.
+// It is given to the types implementing the ContextInterface interface.
+type FieldContext struct {
+	Column  string     // the name of the column. It is empty if the header was not read.
+	Index   int        // the index of the field in the record
+	Row     int        // the number of the record in the file, starting at 1
+	Options TagOptions // the options of the `csv` struct tag. It is empty when scanning.
+	Config  Config     // the configuration of the decoder
+}
+
+// TagOptions holds the options given in a `csv` struct tag,
+// e.g. `csv:"name,option,option=value"`.
+// Options without a value are stored with an empty value.
+type TagOptions map[string]string
+
+// Has reports whether the option is present.
+func (o TagOptions) Has(name string) bool {
+	_, ok := o[name]
+	return ok
+}
+
+// Get returns the value of the option.
+// The boolean is false if the option is not present.
+func (o TagOptions) Get(name string) (string, bool) {
+	v, ok := o[name]
+	return v, ok
+}
+
+// without returns a copy of the options without the named options.
+func (o TagOptions) without(names ...string) TagOptions {
+	c := make(TagOptions, len(o))
+	for k, v := range o {
+		c[k] = v
+	}
+	for _, name := range names {
+		delete(c, name)
+	}
+	return c
+}
@@ -3,15 +3,17 @@ package csvdecoder
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
 	"strconv"
-	"strings"
+	"time"
 )
 
 // convertAssignValues copies to dest the value in src, converting it if possible.
 // An error is returned if the conversion is not possible.
 // dest is expected to be a non-nil pointer type.
-func convertAssignValue(dest interface{}, src string) error {
+// fc describes the field the value was read from.
+func convertAssignValue(dest interface{}, src string, fc *FieldContext) error {
 	dpv := reflect.ValueOf(dest)
 	if dpv.Kind() != reflect.Ptr {
 		return errNotPtr
@@ -25,34 +27,80 @@ func convertAssignValue(dest interface{}, src string) error {
 		return nil
 	}
 
-	// check if the destination implements the Decoder interface
+	// check if a conversion function is registered for the column or the type
+	if fn := fc.Config.converter(fc.Column, dpv.Elem().Type()); fn != nil {
+		return assignConverted(dpv.Elem(), fn, src)
+	}
+
+	// check if the value is restricted to a vocabulary
+	if e := fc.Config.enum(dpv.Elem().Type()); e != nil {
+		return assignEnum(dpv.Elem(), e, src)
+	}
+	if !decodedAsList(dpv.Elem().Type(), fc) {
+		// the values of a list are checked one by one
+		var err error
+		if src, err = oneOf(src, fc); err != nil {
+			return err
+		}
+	}
+
+	// check if the field must be decoded from JSON
+	if fc.Options.Has("json") {
+		return convertAssignJSON(dpv.Elem(), src)
+	}
+
+	// check if the destination implements one of the Decoder interfaces
+	if decoder, ok := dest.(ContextInterface); ok {
+		return decoder.DecodeFieldContext(*fc, src)
+	}
 	if decoder, ok := dest.(Interface); ok {
 		return decoder.DecodeField(src)
 	}
 
-	var sv reflect.Value
-
 	// simple cases without reflect
 	switch d := dest.(type) {
 	case *string:
 		*d = src
 		return nil
 	case *[]byte:
-		*d = []byte(src)
+		b, err := decodeBinary(src, binaryEncoding(fc))
+		if err != nil {
+			return err
+		}
+		*d = b
+		return nil
+	case *json.RawMessage:
+		if !json.Valid([]byte(src)) {
+			return fmt.Errorf("could not parse %s as JSON: %w", src, &JSONError{Path: "$", Err: errInvalidJSON})
+		}
+		*d = json.RawMessage(src)
 		return nil
 	case *bool:
-		bv, err := strconv.ParseBool(src)
+		bv, err := parseBool(src, boolFormat(fc))
 		if err == nil {
 			*d = bv
 		}
 		return err
 	case *interface{}:
-		*d = sv.Interface()
+		*d = inferValue(src, &fc.Config)
 		return nil
+	case *time.Time:
+		return convertAssignTime(d, src, fc)
+	case *big.Int:
+		return convertAssignBigInt(d, src, fc)
+	case *big.Float:
+		return convertAssignBigFloat(d, src, fc)
+	case *big.Rat:
+		return convertAssignBigRat(d, src, fc)
+	}
+
+	// network addresses and identifiers of the standard library
+	if ok, err := convertAssignNetwork(dest, src); ok {
+		return err
 	}
 
 	// cases with reflect
-	sv = reflect.ValueOf(src)
+	sv := reflect.ValueOf(src)
 	dv := reflect.Indirect(dpv)
 
 	if sv.IsValid() && sv.Type().AssignableTo(dv.Type()) {
@@ -65,42 +113,108 @@ func convertAssignValue(dest interface{}, src string) error {
 		return nil
 	}
 
+	if dv.Kind() != reflect.Ptr {
+		if unit, ok := fc.Options.Get("unit"); ok {
+			return convertAssignQuantity(dv, src, unit)
+		}
+		if dv.Type() == durationType {
+			if i64, err := strconv.ParseInt(src, 10, 64); err == nil {
+				// a plain integer is a number of nanoseconds
+				dv.SetInt(i64)
+				return nil
+			}
+			d, err := parseDuration(src)
+			if err != nil {
+				return err
+			}
+			dv.SetInt(int64(d))
+			return nil
+		}
+	}
+
 	switch dv.Kind() {
 	case reflect.Ptr:
 		dv.Set(reflect.New(dv.Type().Elem()))
-		return convertAssignValue(dv.Interface(), src)
+		return convertAssignValue(dv.Interface(), src, fc)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		i64, err := strconv.ParseInt(src, 10, dv.Type().Bits())
+		num, err := normalizeInteger(src, dv.Type(), fc)
+		if err != nil {
+			return err
+		}
+		i64, err := parseInt(num, dv.Type(), intFormat(fc))
 		if err != nil {
 			return err
 		}
 		dv.SetInt(i64)
 		return nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		u64, err := strconv.ParseUint(src, 10, dv.Type().Bits())
+		num, err := normalizeInteger(src, dv.Type(), fc)
+		if err != nil {
+			return err
+		}
+		u64, err := parseUint(num, dv.Type(), intFormat(fc))
 		if err != nil {
 			return err
 		}
 		dv.SetUint(u64)
 		return nil
+	case reflect.Bool:
+		bv, err := parseBool(src, boolFormat(fc))
+		if err != nil {
+			return err
+		}
+		dv.SetBool(bv)
+		return nil
 	case reflect.Float32, reflect.Float64:
-		f64, err := strconv.ParseFloat(src, dv.Type().Bits())
+		format, err := numberFormat(fc)
+		if err != nil {
+			return err
+		}
+		num, percent, err := normalizeNumber(src, format)
+		if err != nil {
+			return err
+		}
+		f64, err := strconv.ParseFloat(num, dv.Type().Bits())
 		if err != nil {
 			return err
 		}
+		if percent {
+			f64 /= 100
+		}
 		dv.SetFloat(f64)
 		return nil
-	case reflect.Slice, reflect.Array:
-		objType := dv.Type()
-		obj := reflect.New(objType).Interface()
-
-		if err := json.NewDecoder(strings.NewReader(src)).Decode(&obj); err != nil {
-			return fmt.Errorf("could not parse %s as JSON array: %w", src, err)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if dv.Kind() != reflect.Map && dv.Type().Elem().Kind() == reflect.Uint8 {
+			if encoding := binaryEncoding(fc); encoding != BinaryRaw {
+				return convertAssignBinary(dv, src, encoding)
+			}
 		}
-
-		dv.Set(reflect.ValueOf(obj).Elem())
-		return nil
+		sep, kvSep, ok, err := listSeparators(fc)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return convertAssignList(dv, src, sep, kvSep, fc)
+		}
+		return convertAssignJSON(dv, src)
 	}
 
 	return fmt.Errorf("unsupported Scan, storing type %T into type %T", src, dest)
 }
+
+// normalizeInteger rewrites the integer in src, written in the number format of the field,
+// in the syntax of the strconv package.
+func normalizeInteger(src string, t reflect.Type, fc *FieldContext) (string, error) {
+	format, err := numberFormat(fc)
+	if err != nil {
+		return "", err
+	}
+	num, percent, err := normalizeNumber(src, format)
+	if err != nil {
+		return "", err
+	}
+	if percent {
+		return "", fmt.Errorf("percentage %q can't be stored into type %s", src, t)
+	}
+	return num, nil
+}
@@ -0,0 +1,535 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// CSVWTable is a table described by the metadata of the W3C CSV on the Web
+// recommendation, see https://www.w3.org/TR/tabular-metadata/. It gives the
+// configuration of the decoder reading the file and the schema of its columns.
+type CSVWTable struct {
+	URL            string  // the URL of the CSV file, as written in the metadata
+	Config         Config  // the configuration of the decoder: delimiter, header, comments and escape character
+	Schema         *Schema // the schema of the columns, decoded with Decoder.DecodeSchema
+	SkipRows       int     // the number of rows skipped before the header
+	HeaderRowCount int     // the number of header rows. Only the first one is kept as header.
+}
+
+// NewDecoder returns a decoder reading the file of the table from r,
+// skipping the rows before the header and the header rows after the first one.
+// The skipped rows are read as CSV records: they can contain quoted line breaks,
+// and the empty lines are not counted.
+func (t *CSVWTable) NewDecoder(r io.Reader) (*Decoder, error) {
+	config := t.Config
+	config.IgnoreHeaders = false
+	d, err := NewWithConfig(r, config)
+	if err != nil {
+		return nil, err
+	}
+	d.config = t.Config
+
+	for i := 0; i < t.SkipRows; i++ {
+		if _, err := d.reader.Read(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		d.rowNumber++
+	}
+	if t.Config.IgnoreHeaders {
+		d.readHeader()
+	}
+	for i := 1; i < t.HeaderRowCount; i++ {
+		if _, err := d.reader.Read(); err != nil {
+			break
+		}
+		d.rowNumber++
+	}
+	return d, nil
+}
+
+// ReadCSVW reads CSV on the Web metadata, e.g. a csv-metadata.json file, describing
+// a table or a group of tables. It returns the tables in the order of the metadata.
+//
+// The dialect properties delimiter, header, headerRowCount, skipRows, commentPrefix and
+// doubleQuote are supported, as well as the column properties name, titles, virtual, null,
+// required and datatype with its format, length and inclusive bounds. The table schemas
+// referenced by URL, the exclusive bounds and the encodings other than UTF-8 are not supported
+// and reported as errors wrapping ErrInvalidSchema.
+func ReadCSVW(r io.Reader) ([]CSVWTable, error) {
+	var group csvwGroup
+	if err := json.NewDecoder(r).Decode(&group); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	tables := group.Tables
+	if tables == nil {
+		// a single table description
+		tables = []csvwTable{group.csvwTable}
+	}
+	result := make([]CSVWTable, 0, len(tables))
+	for _, t := range tables {
+		if t.Dialect == nil {
+			t.Dialect = group.Dialect
+		}
+		if t.TableSchema == nil {
+			t.TableSchema = group.TableSchema
+		}
+		t.csvwInherited = t.csvwInherited.inherit(group.csvwInherited)
+
+		table, err := t.build()
+		if err != nil {
+			if t.URL != "" {
+				return nil, fmt.Errorf("table %s: %w", t.URL, err)
+			}
+			return nil, err
+		}
+		result = append(result, table)
+	}
+	return result, nil
+}
+
+// csvwGroup is the JSON of a table group description, or of a single table description.
+type csvwGroup struct {
+	Tables []csvwTable `json:"tables"`
+	csvwTable
+}
+
+// csvwTable is the JSON of a table description.
+type csvwTable struct {
+	URL         string          `json:"url"`
+	Dialect     *csvwDialect    `json:"dialect"`
+	TableSchema json.RawMessage `json:"tableSchema"`
+	csvwInherited
+}
+
+// csvwDialect is the JSON of a dialect description.
+type csvwDialect struct {
+	Delimiter      *string `json:"delimiter"`
+	Header         *bool   `json:"header"`
+	HeaderRowCount *int    `json:"headerRowCount"`
+	SkipRows       int     `json:"skipRows"`
+	CommentPrefix  *string `json:"commentPrefix"`
+	DoubleQuote    *bool   `json:"doubleQuote"`
+	QuoteChar      *string `json:"quoteChar"`
+	Encoding       string  `json:"encoding"`
+}
+
+// csvwSchema is the JSON of a schema description.
+type csvwSchema struct {
+	Columns    []csvwColumn `json:"columns"`
+	PrimaryKey FieldNames   `json:"primaryKey"`
+	csvwInherited
+}
+
+// csvwColumn is the JSON of a column description.
+type csvwColumn struct {
+	Name    string          `json:"name"`
+	Titles  json.RawMessage `json:"titles"`
+	Virtual bool            `json:"virtual"`
+	csvwInherited
+}
+
+// csvwInherited holds the properties inherited by the columns from their schema,
+// table and group.
+type csvwInherited struct {
+	Null     *FieldNames     `json:"null"`
+	Required *bool           `json:"required"`
+	Datatype json.RawMessage `json:"datatype"`
+}
+
+// inherit returns the properties of p completed by the properties of parent.
+func (p csvwInherited) inherit(parent csvwInherited) csvwInherited {
+	if p.Null == nil {
+		p.Null = parent.Null
+	}
+	if p.Required == nil {
+		p.Required = parent.Required
+	}
+	if p.Datatype == nil {
+		p.Datatype = parent.Datatype
+	}
+	return p
+}
+
+// csvwDatatype is the JSON of a datatype description.
+type csvwDatatype struct {
+	Base         string          `json:"base"`
+	Format       json.RawMessage `json:"format"`
+	Length       *int            `json:"length"`
+	MinLength    *int            `json:"minLength"`
+	MaxLength    *int            `json:"maxLength"`
+	Minimum      json.RawMessage `json:"minimum"`
+	Maximum      json.RawMessage `json:"maximum"`
+	MinInclusive json.RawMessage `json:"minInclusive"`
+	MaxInclusive json.RawMessage `json:"maxInclusive"`
+	MinExclusive json.RawMessage `json:"minExclusive"`
+	MaxExclusive json.RawMessage `json:"maxExclusive"`
+}
+
+// csvwNumberFormat is the JSON of the format of a numeric datatype.
+type csvwNumberFormat struct {
+	Pattern     string `json:"pattern"`
+	DecimalChar string `json:"decimalChar"`
+	GroupChar   string `json:"groupChar"`
+}
+
+// build returns the configuration and the schema of the table.
+func (t csvwTable) build() (CSVWTable, error) {
+	table := CSVWTable{URL: t.URL, HeaderRowCount: 1}
+	table.Config.Comment = '#'
+	if err := t.Dialect.apply(&table); err != nil {
+		return table, err
+	}
+
+	if t.TableSchema == nil {
+		return table, fmt.Errorf("%w: no table schema", ErrInvalidSchema)
+	}
+	var url string
+	if json.Unmarshal(t.TableSchema, &url) == nil {
+		return table, fmt.Errorf("%w: table schema %s referenced by URL is not supported", ErrInvalidSchema, url)
+	}
+	var cs csvwSchema
+	if err := json.Unmarshal(t.TableSchema, &cs); err != nil {
+		return table, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	inherited := cs.csvwInherited.inherit(t.csvwInherited)
+
+	table.Schema = &Schema{PrimaryKey: cs.PrimaryKey}
+	for i, c := range cs.Columns {
+		if c.Virtual {
+			// virtual columns are not in the file
+			continue
+		}
+		f, err := c.field(i, c.csvwInherited.inherit(inherited))
+		if err != nil {
+			return table, err
+		}
+		table.Schema.Fields = append(table.Schema.Fields, f)
+	}
+	if _, err := table.Schema.compile(nil, table.Config); err != nil {
+		return table, err
+	}
+	return table, nil
+}
+
+// apply sets the configuration of the table from the dialect.
+// A nil dialect is the default dialect.
+func (d *csvwDialect) apply(table *CSVWTable) error {
+	if d == nil {
+		table.Config.IgnoreHeaders = true
+		return nil
+	}
+
+	if d.Delimiter != nil {
+		r, size := utf8.DecodeRuneInString(*d.Delimiter)
+		if size == 0 || size != len(*d.Delimiter) {
+			return fmt.Errorf("%w: delimiter %q is not a single character", ErrInvalidSchema, *d.Delimiter)
+		}
+		table.Config.Comma = r
+	}
+	if d.Header != nil && !*d.Header {
+		table.HeaderRowCount = 0
+	}
+	if d.HeaderRowCount != nil {
+		table.HeaderRowCount = *d.HeaderRowCount
+	}
+	table.Config.IgnoreHeaders = table.HeaderRowCount > 0
+	table.SkipRows = d.SkipRows
+
+	if d.CommentPrefix != nil {
+		table.Config.Comment = 0
+		if *d.CommentPrefix != "" {
+			r, size := utf8.DecodeRuneInString(*d.CommentPrefix)
+			if size != len(*d.CommentPrefix) {
+				return fmt.Errorf("%w: comment prefix %q is not a single character", ErrInvalidSchema, *d.CommentPrefix)
+			}
+			table.Config.Comment = r
+		}
+	}
+	if d.DoubleQuote != nil && !*d.DoubleQuote {
+		table.Config.EscapeChar = '\\'
+	}
+	if d.QuoteChar != nil && *d.QuoteChar != `"` {
+		return fmt.Errorf("%w: quote character %q is not supported", ErrInvalidSchema, *d.QuoteChar)
+	}
+	if d.Encoding != "" && !strings.EqualFold(d.Encoding, "utf-8") {
+		return fmt.Errorf("%w: encoding %s is not supported", ErrInvalidSchema, d.Encoding)
+	}
+	return nil
+}
+
+// field returns the schema field of the column at position i.
+func (c csvwColumn) field(i int, p csvwInherited) (SchemaField, error) {
+	f := SchemaField{Name: c.Name, Title: firstTitle(c.Titles)}
+	if f.Name == "" {
+		f.Name = f.Title
+	}
+	if f.Name == "" {
+		f.Name = "_col." + strconv.Itoa(i+1)
+	}
+	if p.Null != nil {
+		f.MissingValues = *p.Null
+	}
+	if p.Required != nil && *p.Required {
+		f.Constraints = &Constraints{Required: true}
+	}
+	if p.Datatype == nil {
+		return f, nil
+	}
+
+	var dt csvwDatatype
+	if err := json.Unmarshal(p.Datatype, &dt.Base); err != nil {
+		if err := json.Unmarshal(p.Datatype, &dt); err != nil {
+			return f, fmt.Errorf("%w: column %s: invalid datatype: %v", ErrInvalidSchema, f.Name, err)
+		}
+	}
+	if err := dt.apply(&f); err != nil {
+		return f, fmt.Errorf("%w: column %s: %v", ErrInvalidSchema, f.Name, err)
+	}
+	return f, nil
+}
+
+// firstTitle returns the first title of a column. The titles are a string, an array
+// of strings or an object mapping languages to titles.
+func firstTitle(raw json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var titles FieldNames
+	if json.Unmarshal(raw, &titles) == nil {
+		if len(titles) > 0 {
+			return titles[0]
+		}
+		return ""
+	}
+	var byLang map[string]FieldNames
+	if json.Unmarshal(raw, &byLang) != nil {
+		return ""
+	}
+	langs := make([]string, 0, len(byLang))
+	for lang := range byLang {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		if len(byLang[lang]) > 0 {
+			return byLang[lang][0]
+		}
+	}
+	return ""
+}
+
+// csvwIntegerBounds are the bounds of the integer datatypes derived from integer.
+var csvwIntegerBounds = map[string][2]string{
+	"long":               {"-9223372036854775808", "9223372036854775807"},
+	"int":                {"-2147483648", "2147483647"},
+	"short":              {"-32768", "32767"},
+	"byte":               {"-128", "127"},
+	"nonNegativeInteger": {"0", ""},
+	"positiveInteger":    {"1", ""},
+	"unsignedLong":       {"0", ""},
+	"unsignedInt":        {"0", "4294967295"},
+	"unsignedShort":      {"0", "65535"},
+	"unsignedByte":       {"0", "255"},
+	"nonPositiveInteger": {"", "0"},
+	"negativeInteger":    {"", "-1"},
+}
+
+// apply sets the type, the format and the constraints of the field from the datatype.
+func (dt csvwDatatype) apply(f *SchemaField) error {
+	if dt.MinExclusive != nil || dt.MaxExclusive != nil {
+		return fmt.Errorf("exclusive bounds are not supported")
+	}
+	var format string
+	if dt.Format != nil {
+		if err := json.Unmarshal(dt.Format, &format); err != nil && dt.Base != "" && !isNumericDatatype(dt.Base) {
+			return fmt.Errorf("invalid format %s", dt.Format)
+		}
+	}
+
+	c := Constraints{Minimum: dt.Minimum, Maximum: dt.Maximum, MinLength: dt.MinLength, MaxLength: dt.MaxLength}
+	if dt.MinInclusive != nil {
+		c.Minimum = dt.MinInclusive
+	}
+	if dt.MaxInclusive != nil {
+		c.Maximum = dt.MaxInclusive
+	}
+	if dt.Length != nil {
+		c.MinLength, c.MaxLength = dt.Length, dt.Length
+	}
+
+	base := dt.Base
+	if base == "" {
+		base = "string"
+	}
+	switch base {
+	case "string", "normalizedString", "token", "language", "Name", "NMTOKEN", "xml", "html", "json", "hexBinary", "any", "anyAtomicType":
+		f.Type = TypeString
+		if format != "" {
+			c.Pattern = format
+		}
+	case "anyURI":
+		f.Type, f.Format = TypeString, "uri"
+	case "base64Binary", "binary":
+		f.Type, f.Format = TypeString, "binary"
+	case "boolean":
+		f.Type = TypeBoolean
+		if format != "" {
+			values := strings.Split(format, "|")
+			if len(values) != 2 {
+				return fmt.Errorf("invalid boolean format %q, expected true|false", format)
+			}
+			f.TrueValues, f.FalseValues = []string{values[0]}, []string{values[1]}
+		}
+	case "decimal", "double", "float", "number":
+		f.Type = TypeNumber
+		if err := dt.applyNumberFormat(f); err != nil {
+			return err
+		}
+	case "integer", "long", "int", "short", "byte",
+		"nonNegativeInteger", "positiveInteger", "unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte",
+		"nonPositiveInteger", "negativeInteger":
+		f.Type = TypeInteger
+		if err := dt.applyNumberFormat(f); err != nil {
+			return err
+		}
+		if bounds, ok := csvwIntegerBounds[base]; ok {
+			if bounds[0] != "" && c.Minimum == nil {
+				c.Minimum = json.RawMessage(bounds[0])
+			}
+			if bounds[1] != "" && c.Maximum == nil {
+				c.Maximum = json.RawMessage(bounds[1])
+			}
+		}
+	case "date", "time", "datetime", "dateTime", "dateTimeStamp", "gYearMonth":
+		f.Type = map[string]FieldType{
+			"date":          TypeDate,
+			"time":          TypeTime,
+			"datetime":      TypeDatetime,
+			"dateTime":      TypeDatetime,
+			"dateTimeStamp": TypeDatetime,
+			"gYearMonth":    TypeYearMonth,
+		}[base]
+		if format != "" {
+			f.Format = uax35Format(format)
+		} else if f.Type == TypeDatetime {
+			f.Format = "any"
+		}
+	case "gYear":
+		f.Type = TypeYear
+	case "duration", "dayTimeDuration":
+		f.Type = TypeDuration
+	default:
+		return fmt.Errorf("unsupported datatype %s", base)
+	}
+
+	if !reflect.DeepEqual(c, Constraints{}) || f.Constraints != nil {
+		if f.Constraints != nil {
+			c.Required = f.Constraints.Required
+		}
+		f.Constraints = &c
+	}
+	return nil
+}
+
+func isNumericDatatype(base string) bool {
+	switch base {
+	case "decimal", "double", "float", "number", "integer":
+		return true
+	}
+	_, ok := csvwIntegerBounds[base]
+	return ok
+}
+
+// applyNumberFormat sets the separators of the numbers from the format of the datatype,
+// either a pattern like #,##0.00 or an object giving the decimal and group characters.
+func (dt csvwDatatype) applyNumberFormat(f *SchemaField) error {
+	if dt.Format == nil {
+		return nil
+	}
+	var nf csvwNumberFormat
+	if err := json.Unmarshal(dt.Format, &nf.Pattern); err != nil {
+		if err := json.Unmarshal(dt.Format, &nf); err != nil {
+			return fmt.Errorf("invalid number format %s", dt.Format)
+		}
+	}
+	if nf.GroupChar == "" && strings.Contains(nf.Pattern, ",") && nf.DecimalChar != "," {
+		nf.GroupChar = ","
+	}
+	f.DecimalChar, f.GroupChar = nf.DecimalChar, nf.GroupChar
+	return nil
+}
+
+// uax35Symbols are the strptime directives of the date field symbols of Unicode
+// Technical Standard #35, as used by the formats of the CSVW datatypes.
+var uax35Symbols = map[string]string{
+	"yyyy": "%Y",
+	"yy":   "%y",
+	"MM":   "%m",
+	"M":    "%-m",
+	"dd":   "%d",
+	"d":    "%-d",
+	"HH":   "%H",
+	"H":    "%-H",
+	"hh":   "%I",
+	"h":    "%-I",
+	"mm":   "%M",
+	"m":    "%-M",
+	"ss":   "%S",
+	"s":    "%-S",
+	"a":    "%p",
+	"X":    "%z",
+	"XX":   "%z",
+	"XXX":  "%:z",
+	"x":    "%z",
+	"xx":   "%z",
+	"xxx":  "%:z",
+}
+
+// uax35Format returns the strptime format of a date format pattern of Unicode Technical
+// Standard #35, e.g. dd.MM.yyyy. The letters that are not field symbols, like the T of
+// yyyy-MM-ddTHH:mm:ss, and the quoted text are literals.
+func uax35Format(pattern string) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		c := pattern[i]
+		j := i
+		for j < len(pattern) && pattern[j] == c {
+			j++
+		}
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(pattern[i+1:], '\'')
+			if end < 0 {
+				end = len(pattern) - i - 1
+			}
+			b.WriteString(strings.ReplaceAll(pattern[i+1:i+1+end], "%", "%%"))
+			j = i + end + 2
+		case c == 'S':
+			// the fraction of the second follows a literal dot
+			b.WriteString("%f")
+		case c == '%':
+			b.WriteString(strings.Repeat("%%", j-i))
+		default:
+			if directive, ok := uax35Symbols[pattern[i:j]]; ok {
+				b.WriteString(directive)
+			} else {
+				b.WriteString(pattern[i:j])
+			}
+		}
+		if j > len(pattern) {
+			j = len(pattern)
+		}
+		i = j
+	}
+	return b.String()
+}
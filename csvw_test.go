@@ -0,0 +1,178 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testCSVW = `{
+  "@context": "http://www.w3.org/ns/csvw",
+  "url": "stations.csv",
+  "dialect": {"delimiter": ";", "skipRows": 1, "headerRowCount": 2},
+  "null": "-",
+  "tableSchema": {
+    "columns": [
+      {"name": "id", "titles": "Station ID", "datatype": "positiveInteger", "required": true},
+      {"name": "name", "titles": {"en": "Name", "de": ["Name"]}},
+      {"name": "opened", "titles": "Opened", "datatype": {"base": "date", "format": "d.M.yyyy"}},
+      {"name": "height", "titles": "Height", "datatype": {"base": "decimal", "format": {"decimalChar": ",", "groupChar": "."}}, "null": ["n/a", ""]},
+      {"name": "staffed", "titles": "Staffed", "datatype": {"base": "boolean", "format": "J|N"}},
+      {"name": "code", "titles": "Code", "datatype": {"base": "string", "format": "[A-Z]{2}[0-9]+", "maxLength": 5}},
+      {"name": "url", "virtual": true, "valueUrl": "http://example.com/{id}"}
+    ],
+    "primaryKey": "id"
+  }
+}`
+
+func TestReadCSVW(t *testing.T) {
+	tables, err := ReadCSVW(strings.NewReader(testCSVW))
+	if err != nil {
+		t.Fatalf("could not read the metadata: %s", err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("expected one table, got %d", len(tables))
+	}
+	table := tables[0]
+	if table.URL != "stations.csv" || table.Config.Comma != ';' || !table.Config.IgnoreHeaders ||
+		table.SkipRows != 1 || table.HeaderRowCount != 2 || table.Config.Comment != '#' {
+		t.Errorf("unexpected table %+v", table)
+	}
+	if len(table.Schema.Fields) != 6 {
+		t.Errorf("expected the virtual column to be skipped, got %+v", table.Schema.Fields)
+	}
+
+	data := "\"Stations of the\nnetwork\"\n" +
+		"Station ID;Name;Opened;Height;Staffed;Code\n" +
+		"int;string;date;decimal;bool;string\n" +
+		"# closed stations are not listed\n" +
+		"7;Nord;3.9.1998;1.204,5;J;AB12\n" +
+		"8;-;-;n/a;N;-\n"
+	d, err := table.NewDecoder(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"id": int64(7), "name": "Nord", "opened": time.Date(1998, 9, 3, 0, 0, 0, 0, time.UTC),
+			"height": 1204.5, "staffed": true, "code": "AB12",
+		},
+		{"id": int64(8), "name": nil, "opened": nil, "height": nil, "staffed": false, "code": nil},
+	}
+	var got []map[string]interface{}
+	for d.Next() {
+		m, err := d.DecodeSchema(table.Schema)
+		if err != nil {
+			t.Error(err)
+		}
+		got = append(got, m)
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, got)
+	}
+}
+
+func TestReadCSVWConstraints(t *testing.T) {
+	tables, err := ReadCSVW(strings.NewReader(testCSVW))
+	if err != nil {
+		t.Fatalf("could not read the metadata: %s", err)
+	}
+	table := tables[0]
+
+	for _, tc := range []struct {
+		name   string
+		row    string
+		column string
+	}{
+		{name: "should check the bounds of the integer datatypes", row: "0;Nord;-;-;J;-", column: "Station ID"},
+		{name: "should check the required columns", row: "-;Nord;-;-;J;-", column: "Station ID"},
+		{name: "should check the format of the strings", row: "1;Nord;-;n/a;J;12AB", column: "Code"},
+		{name: "should check the length of the strings", row: "1;Nord;-;n/a;J;AB1234", column: "Code"},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := table.NewDecoder(strings.NewReader("Stations\nStation ID;Name;Opened;Height;Staffed;Code\ntypes\n" + tc.row + "\n"))
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			if !d.Next() {
+				t.Fatalf("expected a row, got %v", d.Err())
+			}
+			_, err = d.DecodeSchema(table.Schema)
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Column != tc.column || fieldErr.Row != 4 {
+				t.Errorf("expected a field error on row 4, column %q, got %v", tc.column, err)
+			}
+			if !errors.Is(err, ErrValidation) {
+				t.Errorf("expected error %v got %v", ErrValidation, err)
+			}
+		})
+	}
+}
+
+func TestReadCSVWGroup(t *testing.T) {
+	tables, err := ReadCSVW(strings.NewReader(`{
+		"dialect": {"header": false, "doubleQuote": false, "commentPrefix": ""},
+		"tables": [
+			{"url": "a.csv", "tableSchema": {"columns": [{"name": "a", "datatype": "integer"}]}},
+			{"url": "b.csv", "dialect": {"delimiter": "\t"}, "tableSchema": {"columns": [{"titles": "B"}]}}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("could not read the metadata: %s", err)
+	}
+	if len(tables) != 2 {
+		t.Fatalf("expected two tables, got %d", len(tables))
+	}
+
+	a, b := tables[0], tables[1]
+	if a.URL != "a.csv" || a.Config.IgnoreHeaders || a.HeaderRowCount != 0 || a.Config.EscapeChar != '\\' || a.Config.Comment != 0 {
+		t.Errorf("expected the dialect of the group, got %+v", a)
+	}
+	if b.URL != "b.csv" || b.Config.Comma != '\t' || !b.Config.IgnoreHeaders || b.Schema.Fields[0].Name != "B" {
+		t.Errorf("expected the dialect of the table, got %+v", b)
+	}
+}
+
+func TestReadCSVWInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		metadata string
+	}{
+		{name: "should reject invalid JSON", metadata: `{"tableSchema": `},
+		{name: "should reject a missing table schema", metadata: `{"url": "a.csv"}`},
+		{name: "should reject a table schema referenced by URL", metadata: `{"tableSchema": "schema.json"}`},
+		{name: "should reject an unknown datatype", metadata: `{"tableSchema": {"columns": [{"name": "a", "datatype": "QName"}]}}`},
+		{name: "should reject exclusive bounds", metadata: `{"tableSchema": {"columns": [{"name": "a", "datatype": {"base": "integer", "minExclusive": 0}}]}}`},
+		{name: "should reject another quote character", metadata: `{"dialect": {"quoteChar": "'"}, "tableSchema": {"columns": []}}`},
+		{name: "should reject another encoding", metadata: `{"dialect": {"encoding": "latin1"}, "tableSchema": {"columns": []}}`},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadCSVW(strings.NewReader(tc.metadata)); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("expected error %v got %v", ErrInvalidSchema, err)
+			}
+		})
+	}
+}
+
+func TestUAX35Format(t *testing.T) {
+	for pattern, expected := range map[string]string{
+		"dd.MM.yyyy":          "%d.%m.%Y",
+		"M/d/yyyy":            "%-m/%-d/%Y",
+		"yyyy-MM-ddTHH:mm:ss": "%Y-%m-%dT%H:%M:%S",
+		"HH:mm:ss.SSSXXX":     "%H:%M:%S.%f%:z",
+		"h 'h' a":             "%-I h %p",
+	} {
+		if got := uax35Format(pattern); got != expected {
+			t.Errorf("expected format %q for %q, got %q", expected, pattern, got)
+		}
+	}
+}
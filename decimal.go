@@ -0,0 +1,167 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalScale bounds the number of decimals and the exponent of the parsed
+// decimals, so that a large exponent, e.g. 1e200000000, doesn't expand to a huge number.
+const maxDecimalScale = 10000
+
+// Decimal is a fixed-point decimal number, e.g. an amount of money.
+// It holds the exact value written in the CSV field, without going
+// through a float, and keeps its number of decimals: 1.50 has a
+// scale of 2 and is formatted as 1.50.
+//
+// The zero value is 0. A Decimal can be used as a scan target or a struct
+// field and is parsed in the number format of the field.
+type Decimal struct {
+	unscaled *big.Int // the value is unscaled * 10^-scale. nil means 0. It is never modified once set.
+	scale    int32
+}
+
+// NewDecimal returns the decimal unscaled * 10^-scale, e.g. NewDecimal(150, 2) is 1.50.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return Decimal{
+		unscaled: big.NewInt(unscaled),
+		scale:    scale,
+	}
+}
+
+// ParseDecimal returns the decimal written in s, e.g. -12.50 or 1.5e3.
+// The scale of the decimal is the number of digits after the decimal
+// point, adjusted by the exponent and never negative. The number of decimals
+// and the power of ten applied by the exponent are limited to 10000.
+func ParseDecimal(s string) (Decimal, error) {
+	mantissa, exp := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q: invalid exponent", s)
+		}
+		mantissa = s[:i]
+	}
+
+	sign := ""
+	if strings.HasPrefix(mantissa, "-") || strings.HasPrefix(mantissa, "+") {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	intPart, fracPart := mantissa, ""
+	if i := strings.IndexByte(mantissa, '.'); i >= 0 {
+		intPart, fracPart = mantissa[:i], mantissa[i+1:]
+	}
+	if intPart+fracPart == "" || !isDigits(intPart) || !isDigits(fracPart) {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+
+	unscaled, _ := new(big.Int).SetString(sign+intPart+fracPart, 10)
+	scale := int64(len(fracPart)) - exp
+	if scale > maxDecimalScale || scale < -maxDecimalScale {
+		return Decimal{}, fmt.Errorf("invalid decimal %q: exponent out of range", s)
+	}
+	if scale < 0 {
+		unscaled.Mul(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(-scale), nil))
+		scale = 0
+	}
+	return Decimal{
+		unscaled: unscaled,
+		scale:    int32(scale),
+	}, nil
+}
+
+// isDigits reports whether s contains only decimal digits.
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Scale returns the number of decimals.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Unscaled returns the value of the decimal multiplied by 10^scale.
+func (d Decimal) Unscaled() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(d.unscaled)
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of the decimal.
+func (d Decimal) Sign() int {
+	if d.unscaled == nil {
+		return 0
+	}
+	return d.unscaled.Sign()
+}
+
+// Cmp compares d and o and returns -1, 0 or +1 if d is less than, equal to or greater than o.
+// Decimals with different scales can be equal, e.g. 1.5 and 1.50.
+func (d Decimal) Cmp(o Decimal) int {
+	return d.Rat().Cmp(o.Rat())
+}
+
+// Rat returns the exact value of the decimal.
+func (d Decimal) Rat() *big.Rat {
+	denom := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	return new(big.Rat).SetFrac(d.Unscaled(), denom)
+}
+
+// String returns the decimal with all its decimals, e.g. -12.50.
+func (d Decimal) String() string {
+	digits := d.Unscaled()
+	negative := digits.Sign() < 0
+	s := digits.Abs(digits).String()
+	if d.scale > 0 {
+		if len(s) <= int(d.scale) {
+			s = strings.Repeat("0", int(d.scale)-len(s)+1) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(b []byte) error {
+	v, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// DecodeFieldContext implements the csvdecoder.ContextInterface interface.
+// The field is parsed in its number format. A percentage is divided by 100
+// by increasing the scale by 2.
+func (d *Decimal) DecodeFieldContext(fc FieldContext, s string) error {
+	num, percent, err := normalizedDecimal(s, &fc)
+	if err != nil {
+		return err
+	}
+	v, err := ParseDecimal(num)
+	if err != nil {
+		return err
+	}
+	if percent {
+		v.scale += 2
+	}
+	*d = v
+	return nil
+}
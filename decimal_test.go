@@ -0,0 +1,137 @@
+package csvdecoder
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		value         string
+		expected      string
+		expectedScale int32
+		expectedError bool
+	}{
+		{name: "should keep the trailing zeros", value: "12.50", expected: "12.50", expectedScale: 2},
+		{name: "should parse a negative fraction", value: "-0.05", expected: "-0.05", expectedScale: 2},
+		{name: "should parse an integer", value: "+42", expected: "42", expectedScale: 0},
+		{name: "should parse a leading point", value: ".5", expected: "0.5", expectedScale: 1},
+		{name: "should apply a positive exponent", value: "1.5e3", expected: "1500", expectedScale: 0},
+		{name: "should apply a negative exponent", value: "1.5e-3", expected: "0.0015", expectedScale: 4},
+		{name: "should keep digits beyond float64 precision", value: "12345678901234567890.123456789", expected: "12345678901234567890.123456789", expectedScale: 9},
+		{name: "should reject letters", value: "12a", expectedError: true},
+		{name: "should reject a lone point", value: ".", expectedError: true},
+		{name: "should reject two points", value: "1.2.3", expectedError: true},
+		{name: "should accept the largest exponent", value: "1e10000", expected: "1" + strings.Repeat("0", 10000), expectedScale: 0},
+		{name: "should reject a huge exponent", value: "1e200000000", expectedError: true},
+		{name: "should reject a huge negative exponent", value: "1e-200000000", expectedError: true},
+		{name: "should reject too many decimals", value: "0." + strings.Repeat("1", 10001), expectedError: true},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := ParseDecimal(tc.value)
+			if (err != nil) != tc.expectedError {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tc.expectedError {
+				return
+			}
+			if d.String() != tc.expected {
+				t.Errorf("expected value '%s' got '%s'", tc.expected, d.String())
+			}
+			if d.Scale() != tc.expectedScale {
+				t.Errorf("expected scale %d got %d", tc.expectedScale, d.Scale())
+			}
+		})
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	a := NewDecimal(150, 2)
+	b, _ := ParseDecimal("1.5")
+	if a.Cmp(b) != 0 {
+		t.Errorf("expected %s and %s to be equal", a, b)
+	}
+	if (Decimal{}).Cmp(NewDecimal(-1, 3)) != 1 {
+		t.Errorf("expected 0 to be greater than -0.001")
+	}
+	if (Decimal{}).String() != "0" {
+		t.Errorf("expected the zero value to be 0, got %s", Decimal{})
+	}
+}
+
+func TestDecodeBigNumbers(t *testing.T) {
+	type Amounts struct {
+		Total    Decimal    `csv:"total,numfmt=de"`
+		Rate     Decimal    `csv:"rate,percent"`
+		Count    *big.Int   `csv:"count"`
+		Ratio    *big.Rat   `csv:"ratio"`
+		Precise  *big.Float `csv:"precise,prec=200"`
+		Grouped  big.Int    `csv:"grouped,numfmt=en"`
+		Accurate big.Rat    `csv:"accurate"`
+	}
+
+	data := "total;rate;count;ratio;precise;grouped;accurate\n" +
+		"1.234,50;12.5%;123456789012345678901234567890;1/3;0.1;1,000,000;0.1\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true, Comma: ';'})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var a Amounts
+		if err := d.Decode(&a); err != nil {
+			t.Fatal(err)
+		}
+		if a.Total.String() != "1234.50" {
+			t.Errorf("expected total '1234.50' got '%s'", a.Total)
+		}
+		if a.Rate.String() != "0.125" {
+			t.Errorf("expected rate '0.125' got '%s'", a.Rate)
+		}
+		if a.Count.String() != "123456789012345678901234567890" {
+			t.Errorf("expected count '123456789012345678901234567890' got '%s'", a.Count)
+		}
+		if a.Ratio.Cmp(big.NewRat(1, 3)) != 0 {
+			t.Errorf("expected ratio '1/3' got '%s'", a.Ratio)
+		}
+		if a.Precise.Prec() != 200 {
+			t.Errorf("expected a precision of 200 got %d", a.Precise.Prec())
+		}
+		if a.Grouped.Cmp(big.NewInt(1000000)) != 0 {
+			t.Errorf("expected grouped '1000000' got '%s'", &a.Grouped)
+		}
+		if a.Accurate.Cmp(big.NewRat(1, 10)) != 0 {
+			t.Errorf("expected accurate '1/10' got '%s'", &a.Accurate)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestDecodeBigFloatConfig(t *testing.T) {
+	config := Config{BigFloatPrecision: 8, BigFloatRounding: big.ToZero}
+	d, err := NewWithConfig(strings.NewReader("1.999\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var f big.Float
+		if err := d.Scan(&f); err != nil {
+			t.Fatal(err)
+		}
+		if f.Prec() != 8 || f.Mode() != big.ToZero {
+			t.Errorf("unexpected precision %d or mode %s", f.Prec(), f.Mode())
+		}
+		if f.Cmp(big.NewFloat(2)) >= 0 {
+			t.Errorf("expected the value to be rounded towards zero, got %s", f.String())
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
@@ -5,21 +5,55 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/big"
+	"reflect"
 )
 
 type Decoder struct {
 	reader           *csv.Reader
 	config           Config
+	header           []string
 	currentRowValues []string
+	rowNumber        int
 	lastErr          error
+	mappings         map[reflect.Type]*structMapping
+	schemas          map[*Schema]*schemaMapping
 }
 
 // Config is a type that can be used to configure a decoder.
 type Config struct {
-	Comma                  rune // the character that separates values. Default value is comma.
-	IgnoreHeaders          bool // if set to true, the first line will be ignored
-	IgnoreUnmatchingFields bool // if set to true, the number of fields and scan targets are allowed to be different
-	EscapeChar             rune // the character used to escape the quote character in quoted fields. The default is the quote itself.
+	Comma                  rune              // the character that separates values. Default value is comma.
+	IgnoreHeaders          bool              // if set to true, the first line will be ignored. It is kept as header for Decode.
+	IgnoreUnmatchingFields bool              // if set to true, the number of fields and scan targets are allowed to be different
+	EscapeChar             rune              // the character used to escape the quote character in quoted fields. The default is the quote itself.
+	Comment                rune              // if set, the lines starting with this character are skipped
+	ListSeparator          rune              // the character that separates the values of slices, arrays and maps. If not set, slices and arrays are decoded from JSON arrays.
+	KeyValueSeparator      rune              // the character that separates the keys from the values of maps. Default value is '='.
+	NumberFormat           *NumberFormat     // the format of the numbers, e.g. with a decimal comma. If nil, the numbers are parsed by the strconv package.
+	IntFormat              IntFormat         // the syntaxes accepted for the integers in addition to the decimal integers, e.g. hexadecimal.
+	BigFloatPrecision      uint              // the precision in bits of the big.Float values. Default value is 64.
+	BigFloatRounding       big.RoundingMode  // the rounding mode of the big.Float values. Default value is big.ToNearestEven.
+	BinaryEncoding         BinaryEncoding    // the encoding of the []byte and [N]byte values, e.g. base64. Default value is BinaryRaw.
+	BoolFormat             *BoolFormat       // the tokens of the boolean values, e.g. yes and no. If nil, the booleans are parsed by strconv.ParseBool.
+	InferTypes             []InferType       // the types tried in order for values decoded into an interface{}. Default value is int, float, bool, time.
+	TimeLayouts            []string          // the layouts tried in order to infer a time. Default value is RFC 3339 and its date only and space separated variants.
+	Defaults               map[string]string // the values of the empty or missing columns decoded by Decode, keyed by column name. The default tag option takes precedence.
+
+	combiners        map[string]CombineFunc
+	typeConverters   map[reflect.Type]ConvertFunc
+	columnConverters map[string]ConvertFunc
+	numberFormats    map[string]NumberFormat
+	enums            map[reflect.Type]*enum
+}
+
+// RegisterCombiner makes a combine function available under the given name.
+// A struct field tagged with `csv:"name,columns=a|b,combine=<name>"` is decoded
+// by calling fn with the values of the listed columns.
+func (c *Config) RegisterCombiner(name string, fn CombineFunc) {
+	if c.combiners == nil {
+		c.combiners = make(map[string]CombineFunc)
+	}
+	c.combiners[name] = fn
 }
 
 // New returns a new CSV decoder that reads from r.
@@ -36,7 +70,22 @@ func New(r io.Reader) (*Decoder, error) {
 }
 
 func newDecoder(reader io.Reader, config Config) (*Decoder, error) {
-	if config.EscapeChar != defaultEscapeChar {
+	if kvSep := config.KeyValueSeparator; config.ListSeparator != 0 &&
+		(config.ListSeparator == kvSep || kvSep == 0 && config.ListSeparator == defaultKeyValueSeparator) {
+		return nil, fmt.Errorf("the list separator and the key value separator are both %q", config.ListSeparator)
+	}
+	if config.NumberFormat != nil {
+		if err := config.NumberFormat.checkSeparators(); err != nil {
+			return nil, fmt.Errorf("invalid NumberFormat: %w", err)
+		}
+	}
+	if config.BoolFormat != nil {
+		if err := config.BoolFormat.checkTokens(); err != nil {
+			return nil, fmt.Errorf("invalid BoolFormat: %w", err)
+		}
+	}
+	// the zero value stands for the default escape character, read without buffering the input
+	if config.EscapeChar != 0 && config.EscapeChar != defaultEscapeChar {
 		var err error
 		reader, err = NewReaderWithCustomEscape(reader, config.EscapeChar)
 		if err != nil {
@@ -52,18 +101,33 @@ func newDecoder(reader io.Reader, config Config) (*Decoder, error) {
 	if config.Comma != 0 {
 		p.reader.Comma = config.Comma
 	}
+	p.reader.Comment = config.Comment
 
 	p.reader.LazyQuotes = true
 	p.reader.FieldsPerRecord = -1
 
 	if config.IgnoreHeaders {
-		// consume the first line
-		_, _ = p.reader.Read()
+		p.readHeader()
 	}
 
 	return p, nil
 }
 
+// readHeader consumes the next row and keeps it as header.
+func (p *Decoder) readHeader() {
+	header, err := p.reader.Read()
+	if err == nil {
+		p.header = header
+		p.rowNumber++
+	}
+}
+
+// Header returns the names of the columns as read from the first line.
+// It returns nil if the decoder was not configured to read the headers.
+func (p *Decoder) Header() []string {
+	return p.header
+}
+
 // Scan copies the values in the current row into the values pointed
 // at by dest.
 // With the default behavior, it will throw an error if the number of values in dest
@@ -77,20 +141,26 @@ func newDecoder(reader io.Reader, config Config) (*Decoder, error) {
 //    *uint, *uint8, *uint16, *uint32, *uint64
 //    *bool
 //    *float32, *float64
+//    *interface{}, receiving an int64, float64, bool, time.Time or string inferred from the value
 //    a pointer to any type implementing Decoder interface
+//    a pointer to any type implementing ContextInterface interface
 //    a slice of values that can be decoded from a JSON array by the JSON Decoder
 //    an array of values that can be decoded from a JSON array by the JSON Decoder
 //
+// If dest is a single value implementing the RecordDecoder interface, it is given
+// the whole row instead.
+//
 // Scan must not be called concurrently.
 func (p *Decoder) Scan(dest ...interface{}) error {
-	switch {
-	case errors.Is(p.lastErr, ErrEOF):
-		return ErrEOF
-	case p.lastErr != nil:
-		return ErrReadingOccurred
-	case p.currentRowValues == nil:
-		return ErrNextNotCalled
-	case !p.config.IgnoreUnmatchingFields && len(p.currentRowValues) != len(dest):
+	if err := p.checkState(); err != nil {
+		return err
+	}
+	if len(dest) == 1 {
+		if decoder, ok := dest[0].(RecordDecoder); ok {
+			return decoder.DecodeRecord(p.row())
+		}
+	}
+	if !p.config.IgnoreUnmatchingFields && len(p.currentRowValues) != len(dest) {
 		return fmt.Errorf("%w: got %d scan targets and %d fields",
 			ErrScanTargetsNotMatch,
 			len(dest),
@@ -102,14 +172,152 @@ func (p *Decoder) Scan(dest ...interface{}) error {
 			// ignore the remaining fields as they have no scan target
 			break
 		}
-		err := convertAssignValue(dest[i], val)
+		fc := p.row().fieldContext(i)
+		err := convertAssignValue(dest[i], val, &fc)
 		if err != nil {
-			return fmt.Errorf("scan error on value index %d: %w", i, err)
+			return &FieldError{
+				Row:    fc.Row,
+				Index:  i,
+				Column: fc.Column,
+				Err:    err,
+				scan:   true,
+			}
+		}
+	}
+	return nil
+}
+
+// Decode copies the values in the current row into the struct pointed at by dest.
+// The fields of the struct are matched to the columns of the header by name, using
+// the `csv` struct tag if present or the name of the field otherwise. A field
+// tagged with `csv:"-"` is ignored. The header must have been read by setting the
+// `IgnoreHeaders` flag.
+//
+// With the default behavior, it will throw an error if a field has no matching column
+// or a column has no matching field. If the `IgnoreUnmatchingFields` flag is set,
+// those fields and columns are ignored.
+//
+// The values are converted to the type of the fields like in Scan. A field whose
+// type implements the RecordDecoder interface is given the row instead. If the field
+// is tagged with `columns=a|b`, the row only contains the listed columns.
+// A field tagged with `columns=a|b,combine=<name>` is decoded from several columns by
+// the combine function registered under that name with Config.RegisterCombiner.
+//
+// If dest implements the RecordDecoder interface, it is given the row and the
+// fields are not decoded individually.
+//
+// Decode must not be called concurrently.
+func (p *Decoder) Decode(dest interface{}) error {
+	if err := p.checkState(); err != nil {
+		return err
+	}
+	if decoder, ok := dest.(RecordDecoder); ok {
+		return decoder.DecodeRecord(p.row())
+	}
+
+	dpv := reflect.ValueOf(dest)
+	if dpv.Kind() != reflect.Ptr {
+		return errNotPtr
+	}
+	if dpv.IsNil() {
+		return errNilPtr
+	}
+	dv := dpv.Elem()
+	if dv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: got %T", ErrNotStruct, dest)
+	}
+	if p.header == nil {
+		return ErrNoHeaders
+	}
+
+	m, err := p.mapping(dv.Type())
+	if err != nil {
+		return err
+	}
+	if err := m.decode(dv, p.row()); err != nil {
+		return err
+	}
+	if v, ok := dest.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return &FieldError{Row: p.rowNumber, Index: -1, Err: err}
 		}
 	}
 	return nil
 }
 
+// DecodeMap returns the values in the current row keyed by the name of their column.
+// The header must have been read by setting the `IgnoreHeaders` flag.
+// The type of the values is inferred like for a Scan into an *interface{}, using
+// the `InferTypes` and `TimeLayouts` settings. The columns with an empty value are
+// mapped to nil.
+//
+// With the default behavior, it will throw an error if the number of values is different
+// from the number of columns. If the `IgnoreUnmatchingFields` flag is set, the values
+// without a column are ignored.
+//
+// DecodeMap must not be called concurrently.
+func (p *Decoder) DecodeMap() (map[string]interface{}, error) {
+	if err := p.checkState(); err != nil {
+		return nil, err
+	}
+	if p.header == nil {
+		return nil, ErrNoHeaders
+	}
+	if !p.config.IgnoreUnmatchingFields && len(p.currentRowValues) != len(p.header) {
+		return nil, fmt.Errorf("%w: got %d columns and %d fields",
+			ErrScanTargetsNotMatch,
+			len(p.header),
+			len(p.currentRowValues),
+		)
+	}
+
+	row := p.row()
+	m := make(map[string]interface{}, len(p.header))
+	for i, column := range p.header {
+		var v interface{}
+		fc := row.fieldContext(i)
+		if err := convertAssignValue(&v, row.Index(i), &fc); err != nil {
+			return nil, &FieldError{
+				Row:    row.Number(),
+				Index:  i,
+				Column: column,
+				Err:    err,
+			}
+		}
+		m[column] = v
+	}
+	return m, nil
+}
+
+// DecodeSchema returns the values in the current row keyed by the name of the fields
+// of the schema. The values are converted to the types of the fields and the missing
+// values are mapped to nil. If the header was read by setting the `IgnoreHeaders` flag,
+// the fields are matched with the columns by name or title, otherwise by position.
+//
+// The constraints of the schema are checked on every row. A violation is returned
+// as a FieldError wrapping ErrValidation, or ErrNotAllowed for the enum constraints.
+// The unique values and the primary keys are compared with the rows previously
+// decoded with the same schema, and are kept in memory.
+//
+// DecodeSchema must not be called concurrently.
+func (p *Decoder) DecodeSchema(s *Schema) (map[string]interface{}, error) {
+	if err := p.checkState(); err != nil {
+		return nil, err
+	}
+	m, ok := p.schemas[s]
+	if !ok {
+		var err error
+		if m, err = s.compile(p.header, p.config); err != nil {
+			return nil, err
+		}
+		if p.schemas == nil {
+			p.schemas = make(map[*Schema]*schemaMapping)
+		}
+		p.schemas[s] = m
+	}
+	return m.decode(p.row())
+}
+
 // Next prepares the next result row for reading with the Scan method. It
 // returns nil on success, or false if there is no next result row or an error
 // happened while preparing it. Err should be consulted to distinguish between
@@ -128,6 +336,7 @@ func (p *Decoder) Next() bool {
 		p.lastErr = fmt.Errorf("error while reading: %w", err)
 		return false
 	}
+	p.rowNumber++
 	return true
 }
 
@@ -138,3 +347,43 @@ func (p *Decoder) Err() error {
 	}
 	return nil
 }
+
+// checkState returns an error if the current row can't be scanned or decoded.
+func (p *Decoder) checkState() error {
+	switch {
+	case errors.Is(p.lastErr, ErrEOF):
+		return ErrEOF
+	case p.lastErr != nil:
+		return ErrReadingOccurred
+	case p.currentRowValues == nil:
+		return ErrNextNotCalled
+	}
+	return nil
+}
+
+// row returns the current row.
+func (p *Decoder) row() Row {
+	return Row{
+		number: p.rowNumber,
+		header: p.header,
+		values: p.currentRowValues,
+		config: &p.config,
+	}
+}
+
+// mapping returns the mapping of the struct type t to the columns of the header.
+// The mapping is computed once per type.
+func (p *Decoder) mapping(t reflect.Type) (*structMapping, error) {
+	if m, ok := p.mappings[t]; ok {
+		return m, nil
+	}
+	m, err := newStructMapping(t, p.header, p.config)
+	if err != nil {
+		return nil, err
+	}
+	if p.mappings == nil {
+		p.mappings = make(map[reflect.Type]*structMapping)
+	}
+	p.mappings[t] = m
+	return m, nil
+}
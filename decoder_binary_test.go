@@ -0,0 +1,149 @@
+package csvdecoder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBinaryEncoding(t *testing.T) {
+	type Hash [4]byte
+	type Blob []byte
+
+	for _, tc := range []struct {
+		name          string
+		encoding      BinaryEncoding
+		value         string
+		dest          interface{}
+		expected      interface{}
+		expectedError bool
+	}{
+		{
+			name:     "should keep the raw bytes by default",
+			value:    "abc",
+			dest:     new([]byte),
+			expected: []byte("abc"),
+		},
+		{
+			name:     "should decode padded base64",
+			encoding: BinaryBase64,
+			value:    "aGk/Pw==",
+			dest:     new([]byte),
+			expected: []byte("hi??"),
+		},
+		{
+			name:     "should decode unpadded base64",
+			encoding: BinaryBase64,
+			value:    "aGk/Pw",
+			dest:     new(Blob),
+			expected: Blob("hi??"),
+		},
+		{
+			name:     "should decode base64url",
+			encoding: BinaryBase64URL,
+			value:    "aGk_Pw",
+			dest:     new([]byte),
+			expected: []byte("hi??"),
+		},
+		{
+			name:     "should decode padded base64url",
+			encoding: BinaryBase64URL,
+			value:    "aGk_Pw==",
+			dest:     new([]byte),
+			expected: []byte("hi??"),
+		},
+		{
+			name:          "should reject excess padding",
+			encoding:      BinaryBase64,
+			value:         "QQ=====",
+			dest:          new([]byte),
+			expected:      []byte(nil),
+			expectedError: true,
+		},
+		{
+			name:          "should reject partial padding",
+			encoding:      BinaryBase64URL,
+			value:         "QQ=",
+			dest:          new([]byte),
+			expected:      []byte(nil),
+			expectedError: true,
+		},
+		{
+			name:     "should decode hex into an array",
+			encoding: BinaryHex,
+			value:    "DEADbeef",
+			dest:     new(Hash),
+			expected: Hash{0xde, 0xad, 0xbe, 0xef},
+		},
+		{
+			name:          "should reject a wrong array length",
+			encoding:      BinaryHex,
+			value:         "deadbe",
+			dest:          new([4]byte),
+			expected:      [4]byte{},
+			expectedError: true,
+		},
+		{
+			name:          "should reject invalid hex",
+			encoding:      BinaryHex,
+			value:         "xyz0",
+			dest:          new([]byte),
+			expected:      []byte(nil),
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.value+"\n"), Config{BinaryEncoding: tc.encoding})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				err := d.Scan(tc.dest)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if got := reflect.ValueOf(tc.dest).Elem().Interface(); !reflect.DeepEqual(got, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, got)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestBinaryEncodingTag(t *testing.T) {
+	type Document struct {
+		Signature []byte  `csv:"sig,base64"`
+		Hash      [2]byte `csv:"hash,hex"`
+		Token     []byte  `csv:"token,base64url"`
+		Raw       []byte  `csv:"raw"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("sig,hash,token,raw\naGk=,cafe,_-8,plain\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Document{
+		Signature: []byte("hi"),
+		Hash:      [2]byte{0xca, 0xfe},
+		Token:     []byte{0xff, 0xef},
+		Raw:       []byte("plain"),
+	}
+	for d.Next() {
+		var doc Document
+		if err := d.Decode(&doc); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(doc, expected) {
+			t.Errorf("expected value '%v' got '%v'", expected, doc)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
@@ -0,0 +1,161 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBoolFormat(t *testing.T) {
+	yesNo := &BoolFormat{
+		True:            []string{"yes", "y", "on", "ja"},
+		False:           []string{"no", "n", "off", "nein"},
+		CaseInsensitive: true,
+	}
+	strict := &BoolFormat{
+		True:   []string{"Y"},
+		False:  []string{"N"},
+		Strict: true,
+	}
+
+	for _, tc := range []struct {
+		name          string
+		format        *BoolFormat
+		value         string
+		expected      bool
+		expectedError bool
+	}{
+		{
+			name:     "should match a true token",
+			format:   yesNo,
+			value:    "Ja",
+			expected: true,
+		},
+		{
+			name:     "should match a false token",
+			format:   yesNo,
+			value:    "OFF",
+			expected: false,
+		},
+		{
+			name:     "should fall back to strconv when not strict",
+			format:   yesNo,
+			value:    "true",
+			expected: true,
+		},
+		{
+			name:          "should fail for an unknown token when not strict",
+			format:        yesNo,
+			value:         "maybe",
+			expectedError: true,
+		},
+		{
+			name:     "should match the case when case sensitive",
+			format:   strict,
+			value:    "Y",
+			expected: true,
+		},
+		{
+			name:          "should reject other cases when case sensitive",
+			format:        strict,
+			value:         "y",
+			expectedError: true,
+		},
+		{
+			name:          "should reject the strconv values when strict",
+			format:        strict,
+			value:         "true",
+			expectedError: true,
+		},
+		{
+			name:          "should keep the strconv parsing without format",
+			format:        nil,
+			value:         "yes",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.value+"\n"), Config{BoolFormat: tc.format})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var b bool
+				err := d.Scan(&b)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if b != tc.expected {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, b)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestBoolFormatTag(t *testing.T) {
+	type Flag bool
+	type Survey struct {
+		Agreed  bool `csv:"agreed,true=x"`
+		Married Flag `csv:"married,true=ja,false=nein,ignorecase,strict"`
+		Active  bool `csv:"active"`
+	}
+
+	config := Config{IgnoreHeaders: true, BoolFormat: &BoolFormat{True: []string{"Y"}, False: []string{"N"}}}
+	d, err := NewWithConfig(strings.NewReader("agreed,married,active\nx,JA,Y\n,nein,N\nx,vielleicht,Y\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := []Survey{
+		{Agreed: true, Married: true, Active: true},
+		{Agreed: false, Married: false, Active: false},
+		{Agreed: true},
+	}
+	var result []Survey
+	for d.Next() {
+		var s Survey
+		err := d.Decode(&s)
+		if d.row().Number() == 4 {
+			var fieldErr *FieldError
+			if !errors.As(err, &fieldErr) || fieldErr.Row != 4 || fieldErr.Column != "married" {
+				t.Errorf("expected a positioned error, got '%v'", err)
+			}
+		} else if err != nil {
+			t.Error(err)
+		}
+		result = append(result, s)
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, result)
+	}
+}
+
+func TestEmptyBoolToken(t *testing.T) {
+	_, err := NewWithConfig(strings.NewReader("a\nx\n"), Config{BoolFormat: &BoolFormat{True: []string{"x"}, False: []string{""}}})
+	if err == nil {
+		t.Error("expected an error for an empty token in the configuration")
+	}
+
+	d, err := NewWithConfig(strings.NewReader("a\nx\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	var v struct {
+		A bool `csv:"a,true=x,false=no|"`
+	}
+	for d.Next() {
+		if err := d.Decode(&v); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("expected error %v got %v", ErrInvalidTag, err)
+		}
+	}
+}
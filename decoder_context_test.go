@@ -0,0 +1,83 @@
+package csvdecoder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type contextRecorder struct {
+	fc    FieldContext
+	value string
+}
+
+func (c *contextRecorder) DecodeFieldContext(fc FieldContext, s string) error {
+	c.fc = fc
+	c.value = s
+	return nil
+}
+
+// DecodeField must not be called as DecodeFieldContext is preferred.
+func (c *contextRecorder) DecodeField(s string) error {
+	c.value = "DecodeField called"
+	return nil
+}
+
+func TestContextInterfaceScan(t *testing.T) {
+	d, err := NewWithConfig(strings.NewReader("name,city\njohn,Berlin\n"), Config{IgnoreHeaders: true, Comma: ','})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var name string
+		var city contextRecorder
+		if err := d.Scan(&name, &city); err != nil {
+			t.Error(err)
+		}
+		if city.value != "Berlin" {
+			t.Errorf("expected value '%s' got '%s'", "Berlin", city.value)
+		}
+		if city.fc.Column != "city" || city.fc.Index != 1 || city.fc.Row != 2 || city.fc.Config.Comma != ',' {
+			t.Errorf("unexpected field context: %+v", city.fc)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestContextInterfaceDecode(t *testing.T) {
+	type Person struct {
+		Name string          `csv:"name"`
+		City contextRecorder `csv:"city,upper,width=10"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("city,name\nBerlin,john\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var p Person
+		if err := d.Decode(&p); err != nil {
+			t.Error(err)
+		}
+		if p.City.value != "Berlin" {
+			t.Errorf("expected value '%s' got '%s'", "Berlin", p.City.value)
+		}
+		if p.City.fc.Column != "city" || p.City.fc.Index != 0 || p.City.fc.Row != 2 {
+			t.Errorf("unexpected field context: %+v", p.City.fc)
+		}
+		expectedOptions := TagOptions{"upper": "", "width": "10"}
+		if !reflect.DeepEqual(p.City.fc.Options, expectedOptions) {
+			t.Errorf("expected options '%v' got '%v'", expectedOptions, p.City.fc.Options)
+		}
+		if !p.City.fc.Options.Has("upper") {
+			t.Error("expected the upper option to be present")
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
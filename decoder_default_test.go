@@ -0,0 +1,170 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaults(t *testing.T) {
+	type Payment struct {
+		Amount   int     `csv:"amount"`
+		Currency string  `csv:"currency,default=EUR"`
+		Country  *string `csv:"country"`
+		Method   string  `csv:"method,default=card"`
+		Fee      float64 `csv:"fee,numfmt=de"`
+	}
+	de := "DE"
+
+	for _, tc := range []struct {
+		name     string
+		data     string
+		expected []Payment
+	}{
+		{
+			name: "should fill the empty fields",
+			data: "amount,currency,country,method,fee\n10,,,,\n20,USD,US,cash,\"0,5\"\n",
+			expected: []Payment{
+				{Amount: 10, Currency: "EUR", Country: &de, Method: "card", Fee: 1.5},
+				{Amount: 20, Currency: "USD", Country: func() *string { s := "US"; return &s }(), Method: "cash", Fee: 0.5},
+			},
+		},
+		{
+			name: "should fill the missing columns",
+			data: "amount\n10\n",
+			expected: []Payment{
+				{Amount: 10, Currency: "EUR", Country: &de, Method: "card", Fee: 1.5},
+			},
+		},
+		{
+			name: "should prefer the tag option to the configuration",
+			data: "amount,method\n10,\n",
+			expected: []Payment{
+				{Amount: 10, Currency: "EUR", Country: &de, Method: "card", Fee: 1.5},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{
+				IgnoreHeaders: true,
+				Defaults:      map[string]string{"country": "DE", "method": "transfer", "fee": "1,5"},
+			}
+			d, err := NewWithConfig(strings.NewReader(tc.data), config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			var got []Payment
+			for d.Next() {
+				var p Payment
+				if err := d.Decode(&p); err != nil {
+					t.Error(err)
+				}
+				got = append(got, p)
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+			if !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected value '%v' got '%v'", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDefaultsValidation(t *testing.T) {
+	type Payment struct {
+		Amount   int    `csv:"amount,required"`
+		Currency string `csv:"currency,default=EUR,len=3"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("amount,currency\n,\n"), Config{IgnoreHeaders: true, Defaults: map[string]string{"amount": "1"}})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var p Payment
+		if err := d.Decode(&p); err != nil {
+			t.Errorf("expected the defaults to satisfy the rules, got %v", err)
+		}
+		if expected := (Payment{Amount: 1, Currency: "EUR"}); p != expected {
+			t.Errorf("expected value '%v' got '%v'", expected, p)
+		}
+	}
+}
+
+func TestInvalidDefault(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		dest     interface{}
+		defaults map[string]string
+	}{
+		{
+			name: "should reject an invalid default in the tag",
+			dest: &struct {
+				Amount int `csv:"amount,default=ten"`
+			}{},
+		},
+		{
+			name: "should reject an invalid default in the configuration",
+			dest: &struct {
+				Amount int `csv:"amount"`
+			}{},
+			defaults: map[string]string{"amount": "1O"},
+		},
+		{
+			name: "should reject a default matching no column or field",
+			dest: &struct {
+				Amount int `csv:"amount"`
+			}{},
+			defaults: map[string]string{"amount": "1", "curency": "EUR"},
+		},
+		{
+			name: "should reject a default for several columns",
+			dest: &struct {
+				Price Money `csv:"price,columns=amount|currency,default=0"`
+			}{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("amount,currency\n1,EUR\n"), Config{IgnoreHeaders: true, Defaults: tc.defaults, IgnoreUnmatchingFields: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				if err := d.Decode(tc.dest); !errors.Is(err, ErrInvalidTag) {
+					t.Errorf("expected error %v got %v", ErrInvalidTag, err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultsOfOtherColumns(t *testing.T) {
+	d, err := NewWithConfig(strings.NewReader("amount,currency\n1,\n"), Config{
+		IgnoreHeaders:          true,
+		IgnoreUnmatchingFields: true,
+		Defaults:               map[string]string{"currency": "EUR", "country": "DE"},
+	})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	var p struct {
+		Amount  int    `csv:"amount"`
+		Country string `csv:"country"`
+	}
+	for d.Next() {
+		if err := d.Decode(&p); err != nil {
+			t.Errorf("expected the default of a column not decoded to be accepted, got %v", err)
+		}
+	}
+	if p.Country != "DE" {
+		t.Errorf("expected value '%s' got '%s'", "DE", p.Country)
+	}
+}
@@ -0,0 +1,196 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Status string
+
+const (
+	Active   Status = "active"
+	Inactive Status = "inactive"
+)
+
+type Priority int
+
+const (
+	Low Priority = iota + 1
+	High
+)
+
+func TestRegisterEnum(t *testing.T) {
+	type Account struct {
+		Status   Status    `csv:"status"`
+		Priority *Priority `csv:"priority"`
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expected      Account
+		expectedError bool
+	}{
+		{
+			name:     "should map the codes to the values",
+			data:     "A,h",
+			expected: Account{Status: Active, Priority: func() *Priority { p := High; return &p }()},
+		},
+		{
+			name:     "should leave an empty field untouched",
+			data:     "I,",
+			expected: Account{Status: Inactive},
+		},
+		{
+			name:          "should reject a code of another case if case sensitive",
+			data:          "a,l",
+			expectedError: true,
+		},
+		{
+			name:          "should reject an unknown code",
+			data:          "X,l",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{IgnoreHeaders: true}
+			RegisterEnum(&config, map[string]Status{"A": Active, "I": Inactive}, false)
+			RegisterEnum(&config, map[string]Priority{"L": Low, "H": High}, true)
+
+			d, err := NewWithConfig(strings.NewReader("status,priority\n"+tc.data+"\n"), config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var a Account
+				err := d.Decode(&a)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if tc.expectedError {
+					if !errors.Is(err, ErrNotAllowed) {
+						t.Errorf("expected error %v got %v", ErrNotAllowed, err)
+					}
+					return
+				}
+				if !reflect.DeepEqual(a, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, a)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestRegisterEnumErrorListsValues(t *testing.T) {
+	config := Config{}
+	RegisterEnum(&config, map[string]Status{"I": Inactive, "A": Active}, false)
+
+	d, err := NewWithConfig(strings.NewReader("X\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var s Status
+		err := d.Scan(&s)
+		if err == nil || !strings.Contains(err.Error(), `"X" is not one of A, I`) {
+			t.Errorf("expected an error listing the allowed values, got %v", err)
+		}
+	}
+}
+
+func TestOneOfTag(t *testing.T) {
+	type User struct {
+		Status Status `csv:"status,oneof=active|inactive"`
+		Role   string `csv:"role,oneof=Admin|User,ignorecase"`
+		Level  int    `csv:"level,oneof=1|2|3"`
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expected      User
+		expectedError bool
+	}{
+		{
+			name:     "should accept the listed values",
+			data:     "active,Admin,2",
+			expected: User{Status: Active, Role: "Admin", Level: 2},
+		},
+		{
+			name:     "should use the spelling of the tag if case insensitive",
+			data:     "inactive,uSeR,3",
+			expected: User{Status: Inactive, Role: "User", Level: 3},
+		},
+		{
+			name:          "should reject another case if case sensitive",
+			data:          "Active,Admin,1",
+			expectedError: true,
+		},
+		{
+			name:          "should reject an integer that is not listed",
+			data:          "active,Admin,4",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("status,role,level\n"+tc.data+"\n"), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var u User
+				err := d.Decode(&u)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !tc.expectedError && !reflect.DeepEqual(u, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, u)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestOneOfTagList(t *testing.T) {
+	type Color string
+	type Palette struct {
+		Colors []Color           `csv:"colors,split=;,oneof=red|blue"`
+		Shades map[string]string `csv:"shades,split=;,oneof=light|dark"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("colors,shades\nred;blue,red=dark;blue=light\nred;green,\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	d.Next()
+	var p Palette
+	if err := d.Decode(&p); err != nil {
+		t.Fatalf("could not decode: %s", err)
+	}
+	expected := Palette{
+		Colors: []Color{"red", "blue"},
+		Shades: map[string]string{"red": "dark", "blue": "light"},
+	}
+	if !reflect.DeepEqual(p, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, p)
+	}
+
+	d.Next()
+	if err := d.Decode(&Palette{}); !errors.Is(err, ErrNotAllowed) || !strings.Contains(err.Error(), `"green" is not one of red, blue`) {
+		t.Errorf("expected the element green to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type LineItem struct {
+	SKU      string `csv:"sku"`
+	Quantity int    `csv:"qty"`
+}
+
+func TestDecodeGroups(t *testing.T) {
+	type Order struct {
+		ID    string     `csv:"id"`
+		Items []LineItem `csv:"items,pattern=sku_{n}|qty_{n}"`
+		Tags  []string   `csv:"tags,pattern=tag{n}"`
+	}
+
+	for _, tc := range []struct {
+		name     string
+		data     string
+		expected Order
+	}{
+		{
+			name: "should collect the numbered groups in order",
+			data: "id,sku_2,qty_2,sku_1,qty_1,tag1,tag2\no1,B,2,A,1,new,gift\n",
+			expected: Order{
+				ID:    "o1",
+				Items: []LineItem{{SKU: "A", Quantity: 1}, {SKU: "B", Quantity: 2}},
+				Tags:  []string{"new", "gift"},
+			},
+		},
+		{
+			name: "should stop at the first empty group",
+			data: "id,sku_1,qty_1,sku_2,qty_2,sku_3,qty_3,tag1\no1,A,1,,,C,3,\n",
+			expected: Order{
+				ID:    "o1",
+				Items: []LineItem{{SKU: "A", Quantity: 1}},
+				Tags:  []string{},
+			},
+		},
+		{
+			name: "should match padded numbers",
+			data: "id,sku_01,qty_01,tag01\no1,A,1,new\n",
+			expected: Order{
+				ID:    "o1",
+				Items: []LineItem{{SKU: "A", Quantity: 1}},
+				Tags:  []string{"new"},
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var o Order
+				if err := d.Decode(&o); err != nil {
+					t.Error(err)
+				}
+				if !reflect.DeepEqual(o, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, o)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestDecodeGroupsErrors(t *testing.T) {
+	type Order struct {
+		ID    string     `csv:"id"`
+		Items []LineItem `csv:"items,pattern=sku_{n}|qty_{n}"`
+	}
+	type NotSlice struct {
+		Item LineItem `csv:"item,pattern=sku_{n}|qty_{n}"`
+	}
+	type NoPlaceholder struct {
+		Items []LineItem `csv:"items,pattern=sku|qty_{n}"`
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		dest          interface{}
+		expectedError error
+	}{
+		{
+			name:          "should fail for a field that is not a slice",
+			data:          "sku_1,qty_1\nA,1\n",
+			dest:          &NotSlice{},
+			expectedError: ErrInvalidTag,
+		},
+		{
+			name:          "should fail for a template without placeholder",
+			data:          "sku_1,qty_1\nA,1\n",
+			dest:          &NoPlaceholder{},
+			expectedError: ErrInvalidTag,
+		},
+		{
+			name:          "should fail for a conversion error in a group",
+			data:          "id,sku_1,qty_1\no1,A,one\n",
+			dest:          &Order{},
+			expectedError: &FieldError{Row: 2, Index: 2, Column: "qty_1"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				err := d.Decode(tc.dest)
+				if expected, ok := tc.expectedError.(*FieldError); ok {
+					var fieldErr *FieldError
+					if !errors.As(err, &fieldErr) || fieldErr.Row != expected.Row ||
+						fieldErr.Index != expected.Index || fieldErr.Column != expected.Column {
+						t.Errorf("expected '%v', got '%v'", expected, err)
+					}
+					continue
+				}
+				if !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected '%v', got '%v'", tc.expectedError, err)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
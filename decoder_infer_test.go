@@ -0,0 +1,174 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInferValue(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		config   Config
+		value    string
+		expected interface{}
+	}{
+		{
+			name:     "should infer an int",
+			value:    "-42",
+			expected: int64(-42),
+		},
+		{
+			name:     "should keep leading zeros as string",
+			value:    "01234",
+			expected: "01234",
+		},
+		{
+			name:     "should infer a float",
+			value:    "3.25",
+			expected: 3.25,
+		},
+		{
+			name:     "should keep the names of special floats as string",
+			value:    "Nan",
+			expected: "Nan",
+		},
+		{
+			name:     "should keep infinities as string",
+			value:    "Infinity",
+			expected: "Infinity",
+		},
+		{
+			name:     "should keep hexadecimal floats as string",
+			value:    "0x1p-2",
+			expected: "0x1p-2",
+		},
+		{
+			name:     "should keep underscores as string",
+			value:    "1_000.5",
+			expected: "1_000.5",
+		},
+		{
+			name:     "should infer a bool",
+			value:    "TRUE",
+			expected: true,
+		},
+		{
+			name:     "should infer a date",
+			value:    "2020-03-01",
+			expected: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "should infer a time with a configured layout",
+			config:   Config{TimeLayouts: []string{"02/01/2006"}},
+			value:    "01/03/2020",
+			expected: time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "should default to a string",
+			value:    "hello",
+			expected: "hello",
+		},
+		{
+			name:     "should only try the configured types",
+			config:   Config{InferTypes: []InferType{InferFloat}},
+			value:    "42",
+			expected: float64(42),
+		},
+		{
+			name:     "should stop at the string type",
+			config:   Config{InferTypes: []InferType{InferString, InferInt}},
+			value:    "42",
+			expected: "42",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.Comma = ';'
+			d, err := NewWithConfig(strings.NewReader(tc.value+"\n"), tc.config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var v interface{}
+				if err := d.Scan(&v); err != nil {
+					t.Error(err)
+				}
+				if !reflect.DeepEqual(v, tc.expected) {
+					t.Errorf("expected value '%v' (%T) got '%v' (%T)", tc.expected, tc.expected, v, v)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestDecodeMap(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		config        Config
+		data          string
+		expected      []map[string]interface{}
+		expectedError error
+	}{
+		{
+			name:   "should key the inferred values by column",
+			config: Config{IgnoreHeaders: true},
+			data:   "name,age,score,active,note\njohn,44,1.5,true,\n",
+			expected: []map[string]interface{}{
+				{"name": "john", "age": int64(44), "score": 1.5, "active": true, "note": nil},
+			},
+		},
+		{
+			name:          "should fail for a row with more values than columns",
+			config:        Config{IgnoreHeaders: true},
+			data:          "name\njohn,44\n",
+			expected:      []map[string]interface{}{nil},
+			expectedError: ErrScanTargetsNotMatch,
+		},
+		{
+			name:   "should ignore the values without column when the flag is true",
+			config: Config{IgnoreHeaders: true, IgnoreUnmatchingFields: true},
+			data:   "name,age\njohn,44,x\nlucy\n",
+			expected: []map[string]interface{}{
+				{"name": "john", "age": int64(44)},
+				{"name": "lucy", "age": nil},
+			},
+		},
+		{
+			name:          "should fail without header",
+			config:        Config{},
+			data:          "john\n",
+			expected:      []map[string]interface{}{nil},
+			expectedError: ErrNoHeaders,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), tc.config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			var result []map[string]interface{}
+			for d.Next() {
+				m, err := d.DecodeMap()
+				if !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected '%v', got '%v'", tc.expectedError, err)
+				}
+				result = append(result, m)
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected value '%v' got '%v'", tc.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestIntFormat(t *testing.T) {
+	prefixed := IntFormat{BasePrefix: true}
+	fromFloat := IntFormat{FromFloat: true}
+
+	for _, tc := range []struct {
+		name          string
+		format        IntFormat
+		value         string
+		dest          interface{}
+		expected      interface{}
+		expectedError string
+	}{
+		{
+			name:     "should parse a hexadecimal integer",
+			format:   prefixed,
+			value:    "0x1F4",
+			dest:     new(int),
+			expected: 500,
+		},
+		{
+			name:     "should parse a negative octal integer",
+			format:   prefixed,
+			value:    "-0o17",
+			dest:     new(int8),
+			expected: int8(-15),
+		},
+		{
+			name:     "should parse a binary integer",
+			format:   prefixed,
+			value:    "0b1010",
+			dest:     new(uint8),
+			expected: uint8(10),
+		},
+		{
+			name:     "should parse underscores",
+			format:   prefixed,
+			value:    "1_000_000",
+			dest:     new(int64),
+			expected: int64(1000000),
+		},
+		{
+			name:     "should not treat a leading zero as octal",
+			format:   prefixed,
+			value:    "010",
+			dest:     new(int),
+			expected: 10,
+		},
+		{
+			name:          "should reject a prefix without the option",
+			format:        IntFormat{},
+			value:         "0x10",
+			dest:          new(int),
+			expected:      0,
+			expectedError: "invalid syntax",
+		},
+		{
+			name:     "should coerce an exact float",
+			format:   fromFloat,
+			value:    "3.0",
+			dest:     new(int),
+			expected: 3,
+		},
+		{
+			name:     "should coerce an exponent",
+			format:   fromFloat,
+			value:    "1e6",
+			dest:     new(uint32),
+			expected: uint32(1000000),
+		},
+		{
+			name:          "should reject a fractional part",
+			format:        fromFloat,
+			value:         "2.5",
+			dest:          new(int),
+			expected:      0,
+			expectedError: "fractional part would be lost",
+		},
+		{
+			name:          "should name the type and range on overflow",
+			format:        fromFloat,
+			value:         "1e3",
+			dest:          new(int8),
+			expected:      int8(0),
+			expectedError: "1e3 is out of the range of int8 [-128, 127]",
+		},
+		{
+			name:          "should reject a fraction",
+			format:        fromFloat,
+			value:         "6/3",
+			dest:          new(int),
+			expected:      0,
+			expectedError: `invalid integer "6/3"`,
+		},
+		{
+			name:          "should reject a base prefix without the base prefix format",
+			format:        fromFloat,
+			value:         "0x10",
+			dest:          new(int),
+			expected:      0,
+			expectedError: `invalid integer "0x10"`,
+		},
+		{
+			name:          "should reject a huge exponent",
+			format:        fromFloat,
+			value:         "1e200000000",
+			dest:          new(int),
+			expected:      0,
+			expectedError: "exponent out of range",
+		},
+		{
+			name:          "should name the type and range on overflow without format",
+			format:        IntFormat{},
+			value:         "256",
+			dest:          new(uint8),
+			expected:      uint8(0),
+			expectedError: "256 is out of the range of uint8 [0, 255]",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.value+"\n"), Config{IntFormat: tc.format})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				err := d.Scan(tc.dest)
+				switch {
+				case tc.expectedError == "" && err != nil:
+					t.Errorf("unexpected error: %v", err)
+				case tc.expectedError != "" && (err == nil || !strings.Contains(err.Error(), tc.expectedError)):
+					t.Errorf("expected error '%s', got '%v'", tc.expectedError, err)
+				}
+				if got := reflect.ValueOf(tc.dest).Elem().Interface(); !reflect.DeepEqual(got, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, got)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestIntFormatTag(t *testing.T) {
+	type Metric struct {
+		ID    uint16 `csv:"id,baseprefix"`
+		Count int    `csv:"count,fromfloat"`
+		Limit int8   `csv:"limit"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("id,count,limit\n0xFF,1.2e2,3\n1,1,300\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Metric{ID: 255, Count: 120, Limit: 3}
+	d.Next()
+	var m Metric
+	if err := d.Decode(&m); err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, m)
+	}
+
+	d.Next()
+	if err := d.Decode(&m); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("expected '%v', got '%v'", strconv.ErrRange, err)
+	}
+}
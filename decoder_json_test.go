@@ -0,0 +1,122 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Location struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type Celsius float64
+
+// UnmarshalJSON reads the temperature from a JSON string like "21.5C".
+func (c *Celsius) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	var f float64
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(s, "C")), &f); err != nil {
+		return err
+	}
+	*c = Celsius(f)
+	return nil
+}
+
+func TestDecodeJSONObjects(t *testing.T) {
+	type Place struct {
+		Name     string                 `csv:"name"`
+		Location Location               `csv:"location,json"`
+		Pointer  *Location              `csv:"pointer,json"`
+		Meta     map[string]interface{} `csv:"meta"`
+		Raw      json.RawMessage        `csv:"raw"`
+		Temp     Celsius                `csv:"temp,json"`
+	}
+
+	data := `name;location;pointer;meta;raw;temp
+home;{"lat":1.2,"lng":3.4};{"lat":5,"lng":6};{"floor":2,"tags":["a"]};{"any": [1, 2]};"""21.5C"""
+`
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true, Comma: ';'})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Place{
+		Name:     "home",
+		Location: Location{Lat: 1.2, Lng: 3.4},
+		Pointer:  &Location{Lat: 5, Lng: 6},
+		Meta:     map[string]interface{}{"floor": float64(2), "tags": []interface{}{"a"}},
+		Raw:      json.RawMessage(`{"any": [1, 2]}`),
+		Temp:     21.5,
+	}
+	for d.Next() {
+		var p Place
+		if err := d.Decode(&p); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(p, expected) {
+			t.Errorf("expected value '%+v' got '%+v'", expected, p)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestDecodeJSONErrors(t *testing.T) {
+	type Place struct {
+		Location Location        `csv:"location,json"`
+		Raw      json.RawMessage `csv:"raw"`
+	}
+
+	for _, tc := range []struct {
+		name         string
+		data         string
+		expectedPath string
+	}{
+		{
+			name:         "should report the path of a type error",
+			data:         `{"lat":"north","lng":3.4};{}`,
+			expectedPath: "$.lat",
+		},
+		{
+			name:         "should report a syntax error",
+			data:         `{"lat":1.2,;{}`,
+			expectedPath: "$",
+		},
+		{
+			name:         "should validate a raw message",
+			data:         `{"lat":1.2,"lng":3.4};{"a":`,
+			expectedPath: "$",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("location;raw\n"+tc.data+"\n"), Config{IgnoreHeaders: true, Comma: ';'})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var p Place
+				err := d.Decode(&p)
+				var jsonErr *JSONError
+				if !errors.As(err, &jsonErr) {
+					t.Fatalf("expected a JSONError, got '%v'", err)
+				}
+				if jsonErr.Path != tc.expectedPath {
+					t.Errorf("expected path '%s' got '%s'", tc.expectedPath, jsonErr.Path)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
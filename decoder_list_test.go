@@ -0,0 +1,188 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestListSeparator(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		data          string
+		config        Config
+		dest          interface{}
+		expected      interface{}
+		expectedError bool
+	}{
+		{
+			name:     "should split a slice of strings",
+			data:     "a|b|c\n",
+			config:   Config{ListSeparator: '|'},
+			dest:     &[]string{},
+			expected: &[]string{"a", "b", "c"},
+		},
+		{
+			name:     "should convert the values of a slice",
+			data:     "1; 2 ;3\n",
+			config:   Config{ListSeparator: ';', Comma: ','},
+			dest:     &[]int{},
+			expected: &[]int{1, 2, 3},
+		},
+		{
+			name:     "should keep the separator in quoted values",
+			data:     `a|"b|c"|d` + "\n",
+			config:   Config{ListSeparator: '|', Comma: ';'},
+			dest:     &[]string{},
+			expected: &[]string{"a", "b|c", "d"},
+		},
+		{
+			name:     "should fill an array",
+			data:     "1|2\n",
+			config:   Config{ListSeparator: '|'},
+			dest:     &[3]int{},
+			expected: &[3]int{1, 2, 0},
+		},
+		{
+			name:          "should fail for too many array values",
+			data:          "1|2|3|4\n",
+			config:        Config{ListSeparator: '|'},
+			dest:          &[3]int{},
+			expected:      &[3]int{},
+			expectedError: true,
+		},
+		{
+			name:     "should decode a map from a key value list",
+			data:     "a=1;b=2\n",
+			config:   Config{ListSeparator: ';', Comma: ','},
+			dest:     &map[string]int{},
+			expected: &map[string]int{"a": 1, "b": 2},
+		},
+		{
+			name:     "should use the configured key value separator",
+			data:     "1:true;2:false\n",
+			config:   Config{ListSeparator: ';', KeyValueSeparator: ':', Comma: ','},
+			dest:     &map[int]bool{},
+			expected: &map[int]bool{1: true, 2: false},
+		},
+		{
+			name:          "should fail for a missing key value separator",
+			data:          "a=1;b\n",
+			config:        Config{ListSeparator: ';', Comma: ','},
+			dest:          &map[string]int{},
+			expected:      &map[string]int{"a": 1},
+			expectedError: true,
+		},
+		{
+			name:     "should decode JSON when no separator is set",
+			data:     "[1,2]\n",
+			config:   Config{Comma: ';'},
+			dest:     &[]int{},
+			expected: &[]int{1, 2},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), tc.config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				err := d.Scan(tc.dest)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !reflect.DeepEqual(tc.dest, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, tc.dest)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestListTagOptions(t *testing.T) {
+	type Post struct {
+		Tags   []string          `csv:"tags,split=|"`
+		Labels map[string]string `csv:"labels,split=;,kv=:"`
+		Scores []int             `csv:"scores,json"`
+		Matrix [][]int           `csv:"matrix,split=;"`
+	}
+
+	config := Config{IgnoreHeaders: true, ListSeparator: ' '}
+	d, err := NewWithConfig(strings.NewReader("tags,labels,scores,matrix\ngo|csv,env:prod;team:data,\"[1,2]\",1 2;3\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Post{
+		Tags:   []string{"go", "csv"},
+		Labels: map[string]string{"env": "prod", "team": "data"},
+		Scores: []int{1, 2},
+		Matrix: [][]int{{1, 2}, {3}},
+	}
+	for d.Next() {
+		var p Post
+		if err := d.Decode(&p); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(p, expected) {
+			t.Errorf("expected value '%v' got '%v'", expected, p)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestListInvalidTag(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		dest interface{}
+	}{
+		{
+			name: "should reject a separator of several characters",
+			dest: &struct {
+				Tags []string `csv:"tags,split=||"`
+			}{},
+		},
+		{
+			name: "should reject the same list and key value separators",
+			dest: &struct {
+				Tags map[string]string `csv:"tags,split=:,kv=:"`
+			}{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("tags\na:b\n"), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				if err := d.Decode(tc.dest); !errors.Is(err, ErrInvalidTag) {
+					t.Errorf("expected '%v', got '%v'", ErrInvalidTag, err)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestListInvalidConfig(t *testing.T) {
+	for _, config := range []Config{
+		{ListSeparator: ';', KeyValueSeparator: ';'},
+		{ListSeparator: '='},
+	} {
+		if _, err := NewWithConfig(strings.NewReader("a\n"), config); err == nil {
+			t.Errorf("expected an error for the separators %q and %q", config.ListSeparator, config.KeyValueSeparator)
+		}
+	}
+}
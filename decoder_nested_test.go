@@ -0,0 +1,156 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type Address struct {
+	City string `csv:"city"`
+	Zip  string `csv:"zip"`
+}
+
+type Audit struct {
+	CreatedBy string `csv:"created_by"`
+	UpdatedBy string `csv:"updated_by"`
+}
+
+func TestDecodeNested(t *testing.T) {
+	type Customer struct {
+		Audit
+		ID      string   `csv:"id"`
+		Address Address  `csv:"address,prefix=address_"`
+		Billing *Address `csv:"billing,prefix=billing_"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader(
+		"id,address_city,address_zip,billing_city,billing_zip,created_by,updated_by\n"+
+			"c1,Berlin,10115,Hamburg,20095,jane,john\n",
+	), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Customer{
+		Audit:   Audit{CreatedBy: "jane", UpdatedBy: "john"},
+		ID:      "c1",
+		Address: Address{City: "Berlin", Zip: "10115"},
+		Billing: &Address{City: "Hamburg", Zip: "20095"},
+	}
+	for d.Next() {
+		var c Customer
+		if err := d.Decode(&c); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(c, expected) {
+			t.Errorf("expected value '%+v' got '%+v'", expected, c)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestDecodeEmbeddedPointer(t *testing.T) {
+	type Shipment struct {
+		*Audit `csv:",prefix=audit_"`
+		ID     string `csv:"id"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("id,audit_created_by,audit_updated_by\ns1,jane,john\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Shipment{
+		Audit: &Audit{CreatedBy: "jane", UpdatedBy: "john"},
+		ID:    "s1",
+	}
+	for d.Next() {
+		var s Shipment
+		if err := d.Decode(&s); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(s, expected) {
+			t.Errorf("expected value '%+v' got '%+v'", expected, s)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+type Category struct {
+	Name   string    `csv:"name"`
+	Parent *Category `csv:"parent,prefix=parent_"`
+}
+
+func TestDecodeNestedErrors(t *testing.T) {
+	type Shadowed struct {
+		Address
+		City string `csv:"city"`
+	}
+	type Ambiguous struct {
+		Address
+		Other Address `csv:"other,prefix="`
+	}
+	type InvalidPrefix struct {
+		Name string `csv:"name,prefix=x_"`
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		dest          interface{}
+		expectedError error
+	}{
+		{
+			name: "should let the outer field hide the embedded one",
+			data: "city,zip\nBerlin,10115\n",
+			dest: &Shadowed{},
+		},
+		{
+			name:          "should fail when two fields at the same depth match a column",
+			data:          "city,zip\nBerlin,10115\n",
+			dest:          &Ambiguous{},
+			expectedError: ErrAmbiguousColumn,
+		},
+		{
+			name:          "should fail when two columns match a field",
+			data:          "City,CITY,zip\nBerlin,Berlin,10115\n",
+			dest:          &Address{},
+			expectedError: ErrAmbiguousColumn,
+		},
+		{
+			name:          "should fail for a recursive type",
+			data:          "name,parent_name\nshoes,clothes\n",
+			dest:          &Category{},
+			expectedError: ErrInvalidTag,
+		},
+		{
+			name:          "should fail for a prefix on a non struct field",
+			data:          "name\nshoes\n",
+			dest:          &InvalidPrefix{},
+			expectedError: ErrInvalidTag,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				if err := d.Decode(tc.dest); !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected '%v', got '%v'", tc.expectedError, err)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package csvdecoder
+
+import (
+	"errors"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeNetworkTypes(t *testing.T) {
+	type Asset struct {
+		Host    net.IP           `csv:"host"`
+		Subnet  net.IPNet        `csv:"subnet"`
+		MAC     net.HardwareAddr `csv:"mac"`
+		Addr    netip.Addr       `csv:"addr"`
+		Service netip.AddrPort   `csv:"service"`
+		Prefix  netip.Prefix     `csv:"prefix"`
+		Console *url.URL         `csv:"console"`
+		Owner   mail.Address     `csv:"owner"`
+		ID      UUID             `csv:"id"`
+	}
+
+	data := "host,subnet,mac,addr,service,prefix,console,owner,id\n" +
+		"10.0.0.1,10.0.0.0/8,00:1a:2b:3c:4d:5e,2001:db8::1,[::1]:8080,192.168.0.0/16,https://example.com/admin,Jane Doe <jane@example.com>,123E4567-E89B-12D3-A456-426614174000\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Asset{
+		Host:    net.ParseIP("10.0.0.1"),
+		Subnet:  net.IPNet{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+		MAC:     net.HardwareAddr{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e},
+		Addr:    netip.MustParseAddr("2001:db8::1"),
+		Service: netip.MustParseAddrPort("[::1]:8080"),
+		Prefix:  netip.MustParsePrefix("192.168.0.0/16"),
+		Console: &url.URL{Scheme: "https", Host: "example.com", Path: "/admin"},
+		Owner:   mail.Address{Name: "Jane Doe", Address: "jane@example.com"},
+		ID:      UUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00},
+	}
+	for d.Next() {
+		var a Asset
+		if err := d.Decode(&a); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(a, expected) {
+			t.Errorf("expected value '%+v' got '%+v'", expected, a)
+		}
+		if a.ID.String() != "123e4567-e89b-12d3-a456-426614174000" {
+			t.Errorf("unexpected UUID string %s", a.ID)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestDecodeNetworkErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		value string
+		dest  interface{}
+	}{
+		{name: "should reject an invalid IP", value: "10.0.0.300", dest: new(net.IP)},
+		{name: "should reject an invalid CIDR", value: "10.0.0.0/33", dest: new(net.IPNet)},
+		{name: "should reject an invalid MAC", value: "00:1a:2b", dest: new(net.HardwareAddr)},
+		{name: "should reject an invalid netip address", value: "::g", dest: new(netip.Addr)},
+		{name: "should reject an invalid prefix", value: "10.0.0.0", dest: new(netip.Prefix)},
+		{name: "should reject an invalid URL", value: "http://[::1", dest: new(url.URL)},
+		{name: "should reject a URL without scheme", value: "not a url at all", dest: new(url.URL)},
+		{name: "should reject an HTTP URL without host", value: "https:///admin", dest: new(url.URL)},
+		{name: "should reject an invalid email", value: "jane.example.com", dest: new(mail.Address)},
+		{name: "should reject a non canonical UUID", value: "123e4567e89b12d3a456426614174000", dest: new(UUID)},
+		{name: "should reject an invalid UUID digit", value: "123e4567-e89b-12d3-a456-42661417400g", dest: new(UUID)},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("value\n"+tc.value+"\n"), Config{IgnoreHeaders: true, Comma: ';'})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var fieldErr *FieldError
+				if err := d.Scan(tc.dest); !errors.As(err, &fieldErr) || fieldErr.Row != 2 || fieldErr.Column != "value" {
+					t.Errorf("expected a positioned error, got '%v'", err)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
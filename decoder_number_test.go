@@ -0,0 +1,244 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNumberFormat(t *testing.T) {
+	accounting := &NumberFormat{
+		DecimalSeparator: '.',
+		GroupSeparator:   ',',
+		StripCurrency:    true,
+		CurrencyCodes:    []string{"USD"},
+		ParenNegatives:   true,
+		Percent:          true,
+	}
+	european := &NumberFormat{
+		DecimalSeparator: ',',
+		GroupSeparator:   '.',
+		StripCurrency:    true,
+	}
+
+	for _, tc := range []struct {
+		name          string
+		format        *NumberFormat
+		value         string
+		dest          interface{}
+		expected      interface{}
+		expectedError bool
+	}{
+		{
+			name:     "should parse a decimal comma with grouping",
+			format:   european,
+			value:    "1.234,56",
+			dest:     new(float64),
+			expected: 1234.56,
+		},
+		{
+			name:     "should strip a leading currency symbol",
+			format:   european,
+			value:    "€ 4,50",
+			dest:     new(float64),
+			expected: 4.5,
+		},
+		{
+			name:     "should strip a currency symbol after the sign",
+			format:   european,
+			value:    "-€4,50",
+			dest:     new(float64),
+			expected: -4.5,
+		},
+		{
+			name:     "should parse grouped integers",
+			format:   european,
+			value:    "1.234.567",
+			dest:     new(int),
+			expected: 1234567,
+		},
+		{
+			name:          "should reject a decimal point with a decimal comma",
+			format:        european,
+			value:         "1,234.56",
+			dest:          new(float64),
+			expected:      float64(0),
+			expectedError: true,
+		},
+		{
+			name:     "should parse negatives in parentheses",
+			format:   accounting,
+			value:    "(1,234.00)",
+			dest:     new(float64),
+			expected: -1234.0,
+		},
+		{
+			name:     "should strip a trailing currency code",
+			format:   accounting,
+			value:    "(12 USD)",
+			dest:     new(int64),
+			expected: int64(-12),
+		},
+		{
+			name:     "should scale percentages",
+			format:   accounting,
+			value:    "12.5 %",
+			dest:     new(float64),
+			expected: 0.125,
+		},
+		{
+			name:          "should reject percentages for integers",
+			format:        accounting,
+			value:         "12%",
+			dest:          new(int),
+			expected:      0,
+			expectedError: true,
+		},
+		{
+			name:     "should accept a leading plus",
+			format:   accounting,
+			value:    "+$1,000",
+			dest:     new(uint),
+			expected: uint(1000),
+		},
+		{
+			name:          "should reject a second sign",
+			format:        accounting,
+			value:         "+-5",
+			dest:          new(int),
+			expected:      0,
+			expectedError: true,
+		},
+		{
+			name:          "should reject a group of less than three digits",
+			format:        accounting,
+			value:         "1,5",
+			dest:          new(float64),
+			expected:      0.0,
+			expectedError: true,
+		},
+		{
+			name:          "should reject groups of one digit",
+			format:        accounting,
+			value:         "1,2,3,4",
+			dest:          new(int),
+			expected:      0,
+			expectedError: true,
+		},
+		{
+			name:          "should reject an empty group",
+			format:        european,
+			value:         "1..234",
+			dest:          new(int),
+			expected:      0,
+			expectedError: true,
+		},
+		{
+			name:          "should keep the strict parsing without format",
+			format:        nil,
+			value:         "1,000",
+			dest:          new(int),
+			expected:      0,
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.value+"\n"), Config{Comma: ';', NumberFormat: tc.format})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				err := d.Scan(tc.dest)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if got := reflect.ValueOf(tc.dest).Elem().Interface(); !reflect.DeepEqual(got, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, got)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestNumberFormatTag(t *testing.T) {
+	type Report struct {
+		Revenue float64 `csv:"revenue,numfmt=de"`
+		Growth  float64 `csv:"growth,numfmt=de,percent"`
+		Cost    float64 `csv:"cost,numfmt=accounting"`
+		Units   int     `csv:"units"`
+	}
+
+	config := Config{IgnoreHeaders: true, Comma: ';'}
+	config.RegisterNumberFormat("accounting", NumberFormat{GroupSeparator: ',', ParenNegatives: true})
+	d, err := NewWithConfig(strings.NewReader("revenue;growth;cost;units\n1.234,5;12,5%;(1,000.25);7\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Report{Revenue: 1234.5, Growth: 0.125, Cost: -1000.25, Units: 7}
+	for d.Next() {
+		var r Report
+		if err := d.Decode(&r); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(r, expected) {
+			t.Errorf("expected value '%v' got '%v'", expected, r)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestNumberFormatUnknown(t *testing.T) {
+	type Report struct {
+		Revenue float64 `csv:"revenue,numfmt=xx"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("revenue\n1\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var r Report
+		if err := d.Decode(&r); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("expected '%v', got '%v'", ErrInvalidTag, err)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestNumberFormatSameSeparators(t *testing.T) {
+	format := &NumberFormat{DecimalSeparator: ',', GroupSeparator: ','}
+	if _, err := NewWithConfig(strings.NewReader("1,5\n"), Config{Comma: ';', NumberFormat: format}); err == nil {
+		t.Error("expected an error for the same decimal and group separators")
+	}
+
+	type Report struct {
+		Revenue float64 `csv:"revenue,numfmt=dots"`
+	}
+	config := Config{IgnoreHeaders: true}
+	config.RegisterNumberFormat("dots", NumberFormat{GroupSeparator: '.'})
+	d, err := NewWithConfig(strings.NewReader("revenue\n1.5\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	for d.Next() {
+		var r Report
+		if err := d.Decode(&r); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("expected '%v', got '%v'", ErrInvalidTag, err)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
@@ -0,0 +1,193 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuantityTag(t *testing.T) {
+	type Disk struct {
+		Size     int64   `csv:"size,unit=bytes"`
+		Free     uint32  `csv:"free,unit=bytes"`
+		Requests int     `csv:"requests,unit=si"`
+		Current  float64 `csv:"current,unit=si"`
+	}
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expected      Disk
+		expectedError bool
+	}{
+		{
+			name:     "should decode byte sizes and SI prefixes",
+			data:     "1.5GiB,200 MB,3k,250m",
+			expected: Disk{Size: 1610612736, Free: 200000000, Requests: 3000, Current: 0.25},
+		},
+		{
+			name:     "should decode values without unit",
+			data:     "512,1024B,42,1.5",
+			expected: Disk{Size: 512, Free: 1024, Requests: 42, Current: 1.5},
+		},
+		{
+			name:     "should match the byte units regardless of the case",
+			data:     "2kib,1kb,1M,2u",
+			expected: Disk{Size: 2048, Free: 1000, Requests: 1000000, Current: 0.000002},
+		},
+		{
+			name:          "should reject an unknown byte unit",
+			data:          "2XB,1,1,1",
+			expectedError: true,
+		},
+		{
+			name:          "should reject an unknown SI prefix",
+			data:          "1,1,3x,1",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a fraction of a byte",
+			data:          "1.5B,1,1,1",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a size overflowing the type",
+			data:          "1,5GB,1,1",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("size,free,requests,current\n"+tc.data+"\n"), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var disk Disk
+				err := d.Decode(&disk)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !tc.expectedError && !reflect.DeepEqual(disk, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, disk)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestQuantityTagUnknownUnit(t *testing.T) {
+	type Disk struct {
+		Size int64 `csv:"size,unit=parsec"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("size\n1\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	for d.Next() {
+		var disk Disk
+		if err := d.Decode(&disk); !errors.Is(err, ErrInvalidTag) {
+			t.Errorf("expected error %v got %v", ErrInvalidTag, err)
+		}
+	}
+}
+
+func TestDuration(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		value         string
+		expected      time.Duration
+		expectedError bool
+	}{
+		{
+			name:     "should decode a Go duration",
+			value:    "2h30m",
+			expected: 2*time.Hour + 30*time.Minute,
+		},
+		{
+			name:     "should decode days and weeks",
+			value:    "1w2d3h",
+			expected: 9*24*time.Hour + 3*time.Hour,
+		},
+		{
+			name:     "should decode fractional days",
+			value:    "1.5d",
+			expected: 36 * time.Hour,
+		},
+		{
+			name:     "should decode a negative duration",
+			value:    "-1d12h",
+			expected: -36 * time.Hour,
+		},
+		{
+			name:     "should decode an integer as nanoseconds",
+			value:    "1000",
+			expected: time.Microsecond,
+		},
+		{
+			name:          "should reject two signs",
+			value:         "--5d",
+			expectedError: true,
+		},
+		{
+			name:          "should reject mixed signs",
+			value:         "+-1h",
+			expectedError: true,
+		},
+		{
+			name:          "should reject days out of range",
+			value:         "1000000w",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a sum out of range",
+			value:         "106751d23h47m17s",
+			expectedError: true,
+		},
+		{
+			name:          "should reject an ISO duration out of range",
+			value:         "P1000000W",
+			expectedError: true,
+		},
+		{
+			name:          "should reject an unknown unit",
+			value:         "3y",
+			expectedError: true,
+		},
+		{
+			name:          "should reject trailing characters",
+			value:         "3h!",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.value+"\n"), Config{})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var got time.Duration
+				err := d.Scan(&got)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if got != tc.expected {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, got)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package csvdecoder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type Money struct {
+	Amount   int
+	Currency string
+}
+
+// DecodeRecord reads the amount and the currency from the first two fields of the row.
+func (m *Money) DecodeRecord(row Row) error {
+	amount, err := strconv.Atoi(row.Index(0))
+	if err != nil {
+		return err
+	}
+	m.Amount = amount
+	m.Currency = row.Index(1)
+	return nil
+}
+
+type Order struct {
+	ID    string
+	Total Money
+}
+
+// DecodeRecord reads the order from the named columns of the row.
+func (o *Order) DecodeRecord(row Row) error {
+	o.ID, _ = row.Get("id")
+	amount, _ := row.Get("amount")
+	currency, _ := row.Get("currency")
+	return o.Total.DecodeRecord(Row{values: []string{amount, currency}})
+}
+
+func TestRecordDecoder(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		decode   func(d *Decoder, o *Order) error
+		expected Order
+	}{
+		{
+			name:     "should give the row to a Decode target",
+			decode:   func(d *Decoder, o *Order) error { return d.Decode(o) },
+			expected: Order{ID: "o1", Total: Money{Amount: 12, Currency: "EUR"}},
+		},
+		{
+			name:     "should give the row to a single Scan target",
+			decode:   func(d *Decoder, o *Order) error { return d.Scan(o) },
+			expected: Order{ID: "o1", Total: Money{Amount: 12, Currency: "EUR"}},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("currency,id,amount\nEUR,o1,12\n"), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var o Order
+				if err := tc.decode(d, &o); err != nil {
+					t.Error(err)
+				}
+				if !reflect.DeepEqual(o, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, o)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestRecordDecoderField(t *testing.T) {
+	type Line struct {
+		SKU   string `csv:"sku"`
+		Price Money  `csv:"price,columns=amount|currency"`
+		Tax   *Money `csv:"tax,columns=tax|currency"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("sku,currency,amount,tax\nA-1,EUR,12,3\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Line{
+		SKU:   "A-1",
+		Price: Money{Amount: 12, Currency: "EUR"},
+		Tax:   &Money{Amount: 3, Currency: "EUR"},
+	}
+	for d.Next() {
+		var l Line
+		if err := d.Decode(&l); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(l, expected) {
+			t.Errorf("expected value '%v' got '%v'", expected, l)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestCombiner(t *testing.T) {
+	type Line struct {
+		SKU   string `csv:"sku"`
+		Price string `csv:"price,columns=amount|currency,combine=join"`
+	}
+
+	join := func(dest interface{}, values []string) error {
+		s, ok := dest.(*string)
+		if !ok {
+			return fmt.Errorf("unsupported type %T", dest)
+		}
+		*s = strings.Join(values, " ")
+		return nil
+	}
+
+	for _, tc := range []struct {
+		name          string
+		register      bool
+		data          string
+		expected      Line
+		expectedError error
+	}{
+		{
+			name:     "should combine the listed columns",
+			register: true,
+			data:     "sku,currency,amount\nA-1,EUR,12\n",
+			expected: Line{SKU: "A-1", Price: "12 EUR"},
+		},
+		{
+			name:          "should fail when the combiner is not registered",
+			register:      false,
+			data:          "sku,currency,amount\nA-1,EUR,12\n",
+			expectedError: ErrInvalidTag,
+		},
+		{
+			name:          "should fail when a listed column is missing",
+			register:      true,
+			data:          "sku,amount\nA-1,12\n",
+			expectedError: ErrScanTargetsNotMatch,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{IgnoreHeaders: true}
+			if tc.register {
+				config.RegisterCombiner("join", join)
+			}
+			d, err := NewWithConfig(strings.NewReader(tc.data), config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var l Line
+				if err := d.Decode(&l); !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected '%v', got '%v'", tc.expectedError, err)
+				}
+				if !reflect.DeepEqual(l, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, l)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeRestMap(t *testing.T) {
+	type Product struct {
+		SKU        string            `csv:"sku"`
+		Name       string            `csv:"name"`
+		Attributes map[string]string `csv:",rest"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("sku,color,name,size\nA-1,red,shirt,\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Product{
+		SKU:        "A-1",
+		Name:       "shirt",
+		Attributes: map[string]string{"color": "red", "size": ""},
+	}
+	for d.Next() {
+		var p Product
+		if err := d.Decode(&p); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(p, expected) {
+			t.Errorf("expected value '%v' got '%v'", expected, p)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestDecodeRestSlice(t *testing.T) {
+	type Product struct {
+		SKU    string   `csv:"sku"`
+		Others []string `csv:",rest"`
+	}
+
+	for _, tc := range []struct {
+		name     string
+		data     string
+		expected Product
+	}{
+		{
+			name:     "should keep the unmatched values in order",
+			data:     "color,sku,size\nred,A-1,XL\n",
+			expected: Product{SKU: "A-1", Others: []string{"red", "XL"}},
+		},
+		{
+			name:     "should give an empty slice when all columns are matched",
+			data:     "sku\nA-1\n",
+			expected: Product{SKU: "A-1", Others: []string{}},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var p Product
+				if err := d.Decode(&p); err != nil {
+					t.Error(err)
+				}
+				if !reflect.DeepEqual(p, tc.expected) {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, p)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestDecodeRestErrors(t *testing.T) {
+	type WrongType struct {
+		SKU    string         `csv:"sku"`
+		Others map[string]int `csv:",rest"`
+	}
+	type TwoRest struct {
+		SKU    string            `csv:"sku"`
+		Others map[string]string `csv:",rest"`
+		More   []string          `csv:",rest"`
+	}
+
+	for _, tc := range []struct {
+		name string
+		dest interface{}
+	}{
+		{
+			name: "should fail for an unsupported type",
+			dest: &WrongType{},
+		},
+		{
+			name: "should fail for two rest fields",
+			dest: &TwoRest{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("sku,color\nA-1,red\n"), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				if err := d.Decode(tc.dest); !errors.Is(err, ErrInvalidTag) {
+					t.Errorf("expected '%v', got '%v'", ErrInvalidTag, err)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
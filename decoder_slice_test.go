@@ -60,7 +60,7 @@ func TestIntSlice(t *testing.T) {
 				}
 			}
 			if d.Err() != nil {
-				t.Errorf("d error: %w", err)
+				t.Errorf("d error: %v", err)
 			}
 		})
 	}
@@ -120,7 +120,7 @@ func TestMultiLevelIntSlice(t *testing.T) {
 				}
 			}
 			if d.Err() != nil {
-				t.Errorf("d error: %w", err)
+				t.Errorf("d error: %v", err)
 			}
 		})
 	}
@@ -205,7 +205,7 @@ func TestStructSlice(t *testing.T) {
 				}
 			}
 			if d.Err() != nil {
-				t.Errorf("d error: %w", err)
+				t.Errorf("d error: %v", err)
 			}
 		})
 	}
@@ -0,0 +1,122 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStruct(t *testing.T) {
+	type Person struct {
+		Name    string `csv:"name"`
+		Age     int    `csv:"age"`
+		Active  bool
+		Ignored string `csv:"-"`
+		private string
+	}
+
+	for _, tc := range []struct {
+		name          string
+		config        Config
+		data          string
+		expected      []Person
+		expectedError error
+	}{
+		{
+			name:   "should match the columns by tag and by field name",
+			config: Config{IgnoreHeaders: true},
+			data:   "name,age,active\njohn,44,true\nlucy,48,false\n",
+			expected: []Person{
+				{Name: "john", Age: 44, Active: true},
+				{Name: "lucy", Age: 48, Active: false},
+			},
+		},
+		{
+			name:   "should match the columns in any order",
+			config: Config{IgnoreHeaders: true},
+			data:   "Active,age,name\ntrue,44,john\n",
+			expected: []Person{
+				{Name: "john", Age: 44, Active: true},
+			},
+		},
+		{
+			name:          "should fail when a field has no column",
+			config:        Config{IgnoreHeaders: true},
+			data:          "name,age\njohn,44\n",
+			expected:      []Person{{}},
+			expectedError: ErrScanTargetsNotMatch,
+		},
+		{
+			name:          "should fail when a column has no field",
+			config:        Config{IgnoreHeaders: true},
+			data:          "name,age,active,city\njohn,44,true,Berlin\n",
+			expected:      []Person{{}},
+			expectedError: ErrScanTargetsNotMatch,
+		},
+		{
+			name:   "should ignore unmatched fields and columns when the flag is true",
+			config: Config{IgnoreHeaders: true, IgnoreUnmatchingFields: true},
+			data:   "name,city\njohn,Berlin\n",
+			expected: []Person{
+				{Name: "john"},
+			},
+		},
+		{
+			name:          "should fail when the header was not read",
+			config:        Config{},
+			data:          "john,44,true\n",
+			expected:      []Person{{}},
+			expectedError: ErrNoHeaders,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(tc.data), tc.config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			var result []Person
+			for d.Next() {
+				var p Person
+				if err := d.Decode(&p); !errors.Is(err, tc.expectedError) {
+					t.Errorf("expected '%v', got '%v'", tc.expectedError, err)
+				}
+				result = append(result, p)
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("expected value '%v' got '%v'", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestDecodeFieldError(t *testing.T) {
+	type Person struct {
+		Name string `csv:"name"`
+		Age  int    `csv:"age"`
+	}
+
+	d, err := NewWithConfig(strings.NewReader("name,age\njohn,44\nlucy,old\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	var fieldErr *FieldError
+	for d.Next() {
+		var p Person
+		if err := d.Decode(&p); err != nil && !errors.As(err, &fieldErr) {
+			t.Errorf("expected a FieldError, got '%v'", err)
+		}
+	}
+	if fieldErr == nil {
+		t.Fatal("expected a FieldError")
+	}
+	if fieldErr.Row != 3 || fieldErr.Index != 1 || fieldErr.Column != "age" {
+		t.Errorf("unexpected error position: %+v", fieldErr)
+	}
+}
@@ -121,3 +121,22 @@ func TestIgnoreUnmatchingFields(t *testing.T) {
 		})
 	}
 }
+
+func TestScanError(t *testing.T) {
+	d, err := NewWithConfig(strings.NewReader("name,age\njohn,old\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	d.Next()
+	var name string
+	var age int
+	err = d.Scan(&name, &age)
+	if err == nil || !strings.HasPrefix(err.Error(), "scan error on value index 1: ") {
+		t.Errorf("expected the scan error message, got '%v'", err)
+	}
+	var fieldErr *FieldError
+	if !errors.As(err, &fieldErr) || fieldErr.Row != 2 || fieldErr.Index != 1 || fieldErr.Column != "age" {
+		t.Errorf("expected a positioned error, got '%v'", err)
+	}
+}
@@ -0,0 +1,95 @@
+package csvdecoder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeTime(t *testing.T) {
+	type Event struct {
+		Day     time.Time     `csv:"day,layout=02/01/2006|2006-01-02"`
+		At      *time.Time    `csv:"at"`
+		Timeout time.Duration `csv:"timeout"`
+	}
+
+	data := "day,at,timeout\n31/01/2020,2020-01-31T10:00:00Z,PT1H30M\n2020-02-01,,90s\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	var got []Event
+	for d.Next() {
+		var e Event
+		if err := d.Decode(&e); err != nil {
+			t.Fatalf("could not decode: %s", err)
+		}
+		got = append(got, e)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(got))
+	}
+	if !got[0].Day.Equal(time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)) || got[0].At == nil ||
+		!got[0].At.Equal(time.Date(2020, 1, 31, 10, 0, 0, 0, time.UTC)) || got[0].Timeout != 90*time.Minute {
+		t.Errorf("unexpected event %+v", got[0])
+	}
+	if !got[1].Day.Equal(time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)) || got[1].At != nil || got[1].Timeout != 90*time.Second {
+		t.Errorf("unexpected event %+v", got[1])
+	}
+
+	d, err = NewWithConfig(strings.NewReader("day,at,timeout\n01.02.2020,,\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	d.Next()
+	var e Event
+	if err := d.Decode(&e); err == nil || !strings.Contains(err.Error(), `could not parse "01.02.2020" as a time`) {
+		t.Errorf("expected a time error, got %v", err)
+	}
+}
+
+func TestDecodeAliasAndNull(t *testing.T) {
+	type Order struct {
+		ID    int64    `csv:"order_id,alias=Order ID|id"`
+		Total *float64 `csv:"total,null=NA|-"`
+		Note  string   `csv:"note,null=NA,default=none"`
+	}
+
+	for _, data := range []string{
+		"order_id,total,note\n1,NA,NA\n",
+		"Order ID,total,note\n1,-,\n",
+		"id,total,note\n1,,NA\n",
+	} {
+		d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+		if err != nil {
+			t.Fatalf("could not create d: %s", err)
+		}
+		d.Next()
+		var o Order
+		if err := d.Decode(&o); err != nil {
+			t.Fatalf("could not decode %q: %s", data, err)
+		}
+		if o.ID != 1 || o.Total != nil || o.Note != "none" {
+			t.Errorf("unexpected order %+v for %q", o, data)
+		}
+	}
+
+	type Invalid struct {
+		Items []string `csv:"items,pattern=item_{n},alias=item"`
+	}
+	d, err := NewWithConfig(strings.NewReader("item_1\na\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	d.Next()
+	var v Invalid
+	if err := d.Decode(&v); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package csvdecoder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type Booking struct {
+	Email     string   `csv:"email,required,email"`
+	Code      string   `csv:"code,len=3,regex=^[A-Z]{2,3}$"`
+	Guests    int      `csv:"guests,min=1,max=4"`
+	Name      *string  `csv:"name,min=2"`
+	Tags      []string `csv:"tags,split=|,max=2"`
+	Arrival   string   `csv:"arrival"`
+	Departure string   `csv:"departure,gt=Arrival"`
+	Price     float64  `csv:"price"`
+	Discount  float64  `csv:"discount,lt=Price"`
+}
+
+type validatedBooking struct {
+	Booking
+}
+
+func (b *validatedBooking) Validate() error {
+	if b.Guests > 2 && b.Price < 100 {
+		return errors.New("the price is too low for the guests")
+	}
+	return nil
+}
+
+func TestValidationRules(t *testing.T) {
+	const header = "email,code,guests,name,tags,arrival,departure,price,discount\n"
+	const valid = "jo@example.com,ABC,2,Jo,a|b,2024-05-01,2024-05-03,90,10"
+
+	for _, tc := range []struct {
+		name          string
+		data          string
+		column        string
+		expectedError bool
+	}{
+		{
+			name: "should accept valid values",
+			data: valid,
+		},
+		{
+			name: "should not check the rules of empty fields other than required",
+			data: "jo@example.com,,,,,,,,",
+		},
+		{
+			name:          "should reject a missing required value",
+			data:          strings.Replace(valid, "jo@example.com", "", 1),
+			column:        "email",
+			expectedError: true,
+		},
+		{
+			name:          "should reject an invalid email",
+			data:          strings.Replace(valid, "jo@example.com", "Jo <jo@example.com>", 1),
+			column:        "email",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a wrong length",
+			data:          strings.Replace(valid, "ABC", "ABCD", 1),
+			column:        "code",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a value not matching the regex",
+			data:          strings.Replace(valid, "ABC", "abc", 1),
+			column:        "code",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a value less than the minimum",
+			data:          strings.Replace(valid, ",2,", ",0,", 1),
+			column:        "guests",
+			expectedError: true,
+		},
+		{
+			name:          "should reject a value greater than the maximum",
+			data:          strings.Replace(valid, ",2,", ",5,", 1),
+			column:        "guests",
+			expectedError: true,
+		},
+		{
+			name:          "should check the length of a string pointer",
+			data:          strings.Replace(valid, ",Jo,", ",J,", 1),
+			column:        "name",
+			expectedError: true,
+		},
+		{
+			name:          "should check the length of a slice",
+			data:          strings.Replace(valid, "a|b", "a|b|c", 1),
+			column:        "tags",
+			expectedError: true,
+		},
+		{
+			name:          "should compare strings with another field",
+			data:          strings.Replace(valid, "2024-05-03", "2024-04-30", 1),
+			column:        "departure",
+			expectedError: true,
+		},
+		{
+			name:          "should compare numbers with another field",
+			data:          strings.Replace(valid, ",90,10", ",90,90", 1),
+			column:        "discount",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader(header+tc.data+"\n"), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var b struct{ Booking }
+				err := d.Decode(&b)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if !tc.expectedError {
+					continue
+				}
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Column != tc.column || fieldErr.Row != 2 {
+					t.Errorf("expected a field error on column %q, got %v", tc.column, err)
+				}
+				if !errors.Is(err, ErrValidation) {
+					t.Errorf("expected error %v got %v", ErrValidation, err)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestValidationRulesInvalidTag(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		dest interface{}
+	}{
+		{
+			name: "should reject an unknown field",
+			dest: &struct {
+				A int `csv:"a,gt=Missing"`
+			}{},
+		},
+		{
+			name: "should reject an invalid regex",
+			dest: &struct {
+				A string `csv:"a,regex=[a-"`
+			}{},
+		},
+		{
+			name: "should reject an invalid regex on a custom decoder",
+			dest: &struct {
+				A contextRecorder `csv:"a,upper,regex=[a-"`
+			}{},
+		},
+		{
+			name: "should reject max on a custom decoder without length",
+			dest: &struct {
+				A contextRecorder `csv:"a,max=10"`
+			}{},
+		},
+		{
+			name: "should reject min on a bool",
+			dest: &struct {
+				A bool `csv:"a,min=1"`
+			}{},
+		},
+		{
+			name: "should reject the comparison of a number with a string",
+			dest: &struct {
+				A int    `csv:"a,lt=B"`
+				B string `csv:"b"`
+			}{},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewWithConfig(strings.NewReader("a,b\n1,2\n"), Config{IgnoreHeaders: true, IgnoreUnmatchingFields: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				if err := d.Decode(tc.dest); !errors.Is(err, ErrInvalidTag) {
+					t.Errorf("expected error %v got %v", ErrInvalidTag, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateHook(t *testing.T) {
+	const header = "email,code,guests,name,tags,arrival,departure,price,discount\n"
+
+	d, err := NewWithConfig(strings.NewReader(header+
+		"jo@example.com,ABC,2,Jo,a,,,90,10\n"+
+		"jo@example.com,ABC,3,Jo,a,,,90,10\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	var errs []error
+	for d.Next() {
+		var b validatedBooking
+		errs = append(errs, d.Decode(&b))
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+
+	if len(errs) != 2 || errs[0] != nil {
+		t.Fatalf("expected the first row to be valid, got %v", errs)
+	}
+	var fieldErr *FieldError
+	if !errors.As(errs[1], &fieldErr) || fieldErr.Row != 3 || fieldErr.Index != -1 {
+		t.Errorf("expected a field error on row 3, got %v", errs[1])
+	}
+	if got, expected := errs[1].Error(), "decode error on row 3: the price is too low for the guests"; got != expected {
+		t.Errorf("expected error %q got %q", expected, got)
+	}
+}
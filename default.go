@@ -0,0 +1,110 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// setDefault sets the default value of the field f of type t, given by the default
+// tag option or by Config.Defaults for the column of the field. The default value is
+// converted once to report the invalid defaults when the mapping is built.
+func (b *mappingBuilder) setDefault(f *fieldMapping, t reflect.Type) error {
+	configValue, configured := b.config.Defaults[f.name]
+	if configured {
+		if b.defaulted == nil {
+			b.defaulted = make(map[string]bool)
+		}
+		b.defaulted[f.name] = true
+	}
+	value, ok := f.options.Get("default")
+	if !ok {
+		value, ok = configValue, configured
+	}
+	if !ok {
+		return nil
+	}
+	if f.record || f.options.Has("pattern") || f.options.Has("columns") {
+		return fmt.Errorf("%w: field %s: a default value requires a field decoded from a single column", ErrInvalidTag, f.path)
+	}
+
+	fc := FieldContext{
+		Column:  f.name,
+		Index:   -1,
+		Options: f.options,
+		Config:  b.config,
+	}
+	if err := convertAssignValue(reflect.New(t).Interface(), value, &fc); err != nil {
+		return fmt.Errorf("%w: field %s: invalid default value %q: %v", ErrInvalidTag, f.path, value, err)
+	}
+	f.defaultValue = &value
+	return nil
+}
+
+// checkDefaults returns an error if a key of Config.Defaults names neither
+// a column of the header nor a field, e.g. because of a typo.
+func (b *mappingBuilder) checkDefaults() error {
+	var unknown []string
+	for name := range b.config.Defaults {
+		if b.defaulted[name] {
+			continue
+		}
+		if idx, err := columnIndex(b.header, name); idx < 0 && err == nil {
+			unknown = append(unknown, strconv.Quote(name))
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("%w: the defaults of the columns %s match no column or field", ErrInvalidTag, strings.Join(unknown, ", "))
+}
+
+// setNull sets the values of the field f decoded like an empty field, given by the
+// null tag option and separated by '|', e.g. null=NA|-.
+func (f *fieldMapping) setNull() error {
+	tokens, ok := f.options.Get("null")
+	if !ok {
+		return nil
+	}
+	if f.record || f.options.Has("pattern") || f.options.Has("columns") {
+		return fmt.Errorf("%w: field %s: null requires a field decoded from a single column", ErrInvalidTag, f.path)
+	}
+	f.nulls = strings.Split(tokens, "|")
+	return nil
+}
+
+// value returns the CSV field of f in row, or the default value of f
+// if the field is empty or null or the column is missing.
+func (f fieldMapping) value(row Row) string {
+	var v string
+	if len(f.columns) > 0 {
+		v = row.Index(f.columns[0])
+	}
+	for _, null := range f.nulls {
+		if v == null {
+			v = ""
+			break
+		}
+	}
+	if v == "" && f.defaultValue != nil {
+		return *f.defaultValue
+	}
+	return v
+}
+
+// fieldContext returns the context of the single column field f in row.
+func (f fieldMapping) fieldContext(row Row) FieldContext {
+	var fc FieldContext
+	if len(f.columns) > 0 {
+		fc = row.fieldContext(f.columns[0])
+	} else {
+		// the column is missing
+		fc = row.fieldContext(-1)
+		fc.Column = f.name
+	}
+	fc.Options = f.options
+	return fc
+}
@@ -12,9 +12,20 @@
 //	*uint, *uint8, *uint16, *uint32, *uint64
 //	*bool
 //	*float32, *float64
+//	*time.Duration, also accepting the d and w units
+//	*[]byte and byte arrays, optionally base64 or hex encoded
+//	*big.Int, *big.Float, *big.Rat
+//	*csvdecoder.Decimal, a fixed-point decimal number
+//	*net.IP, *net.IPNet, *net.HardwareAddr, *netip.Addr, *netip.AddrPort, *netip.Prefix, *url.URL, *mail.Address
+//	*csvdecoder.UUID
+//	*interface{}, receiving an int64, float64, bool, time.Time or string inferred from the field
 //	a slice of values. Note that the CSV field must be a valid JSON array. If not a JSON array, a custom decoder implementing the csvdecoder.Interface interface must be implemented.
 //	an array of values. Note that the CSV field must be a valid JSON array. If not a JSON array, a custom decoder implementing the csvdecoder.Interface interface must be implemented.
+//	a map of values. Note that the CSV field must be a valid JSON object, unless a list separator is configured.
+//	a json.RawMessage. Note that the CSV field must be a valid JSON value.
+//	any type decodable from JSON, if the struct field is tagged with the json option
 //	a pointer to any type implementing the csvdecoder.Interface interface
+//	a pointer to any type implementing the csvdecoder.ContextInterface interface, which is also given the position of the field
 //
 // csvdecoder uses the same terminology as package encoding/csv:
 // A csv file contains zero or more records. Each record contains one or more
@@ -27,6 +38,34 @@
 //	Comma: the character that separates values. The default value is comma.
 //	IgnoreHeaders: if set to true, the first line will be ignored. This is useful when the CSV file contains a header line.
 //	IgnoreUnmatchingFields: if set to true, the number of fields and scan targets are allowed to be different. By default, if they don't match exactly it will cause an error.
+//	Comment: if set, the lines starting with this character are skipped.
+//	ListSeparator: the character that separates the values of slices, arrays and maps. If not set, slices and arrays are decoded from JSON arrays.
+//	KeyValueSeparator: the character that separates the keys from the values of maps. The default value is '='.
+//	NumberFormat: the format of the numbers, e.g. with a decimal comma, grouping separators or currency symbols.
+//	IntFormat: the syntaxes accepted for the integers, e.g. with a base prefix or as floats without fractional part.
+//	BigFloatPrecision, BigFloatRounding: the precision and rounding mode of the big.Float values.
+//	BinaryEncoding: the encoding of the []byte and [N]byte values, e.g. base64 or hex.
+//	BoolFormat: the tokens of the boolean values, e.g. yes and no.
+//	InferTypes: the types tried in order for values decoded into an interface{}.
+//	TimeLayouts: the layouts tried in order to infer a time.Time.
+//	Defaults: the values of the empty or missing columns decoded into structs.
+//
+// If the CSV file has a header line, the fields of a record can also be decoded into
+// the fields of a struct (using 'Decode'). The columns are matched by the name given
+// in the `csv` struct tag or by the name of the struct field.
+// Types implementing the csvdecoder.RecordDecoder interface are given the whole record,
+// which allows a Go value to span several columns.
+// The decoded fields can be validated with tag options, e.g. `csv:"age,required,min=18"`,
+// and by a Validate method of the struct.
+//
+// The fields of a record can also be decoded without Go struct, with a Schema describing
+// their types and constraints (using 'DecodeSchema'). A Schema is read from and written to
+// the JSON of the Frictionless Table Schema specification. The configuration and the Schema
+// of the tables described by CSV on the Web metadata are read by ReadCSVW. A Schema can be
+// inferred from a sample of a file by Infer, and the Go struct decoding the rows described
+// by a Schema is written by GenerateStruct or by the gen-struct command of cmd/csvdecoder.
+// The values of the columns of a file are described by 'Profile', and the unique, primary
+// and foreign keys across rows and files are checked by 'CheckKeys'.
 //
 // See README.md for more info.
 package csvdecoder
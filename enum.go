@@ -0,0 +1,112 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// enum is a vocabulary of CSV fields mapped to values of a type.
+type enum struct {
+	values          map[string]reflect.Value
+	names           []string // the sorted names of the values, for the error messages
+	caseInsensitive bool
+}
+
+// RegisterEnum registers the vocabulary of the type T: the CSV fields are mapped to
+// values of type T, e.g. "A" to Active. A field that is not in the vocabulary is an error
+// listing the allowed values. If caseInsensitive is true, the fields are matched regardless
+// of the case. It is used for all the scan targets and struct fields of type T (or pointer to T).
+func RegisterEnum[T any](c *Config, values map[string]T, caseInsensitive bool) {
+	e := &enum{
+		values:          make(map[string]reflect.Value, len(values)),
+		caseInsensitive: caseInsensitive,
+	}
+	for name, v := range values {
+		v := v
+		e.values[name] = reflect.ValueOf(&v).Elem()
+		e.names = append(e.names, name)
+	}
+	sort.Strings(e.names)
+
+	if c.enums == nil {
+		c.enums = make(map[reflect.Type]*enum)
+	}
+	c.enums[reflect.TypeOf((*T)(nil)).Elem()] = e
+}
+
+// enum returns the vocabulary registered for the type t, or nil if there is none.
+func (c *Config) enum(t reflect.Type) *enum {
+	for {
+		if e, ok := c.enums[t]; ok {
+			return e
+		}
+		if t.Kind() != reflect.Ptr {
+			return nil
+		}
+		t = t.Elem()
+	}
+}
+
+// lookup returns the value of the vocabulary named by src.
+func (e *enum) lookup(src string) (reflect.Value, error) {
+	if v, ok := e.values[src]; ok {
+		return v, nil
+	}
+	if e.caseInsensitive {
+		for _, name := range e.names {
+			if strings.EqualFold(name, src) {
+				return e.values[name], nil
+			}
+		}
+	}
+	return reflect.Value{}, notOneOfError(src, e.names)
+}
+
+// assignEnum stores in dv the value of the vocabulary named by src,
+// allocating the pointers on the way if needed.
+func assignEnum(dv reflect.Value, e *enum, src string) error {
+	v, err := e.lookup(src)
+	if err != nil {
+		return err
+	}
+	for v.Type() != dv.Type() {
+		if dv.IsNil() {
+			dv.Set(reflect.New(dv.Type().Elem()))
+		}
+		dv = dv.Elem()
+	}
+	dv.Set(v)
+	return nil
+}
+
+// oneOf checks src against the values allowed by the oneof tag option, if any,
+// and returns it with the spelling of the tag. The values are separated by |
+// and are matched regardless of the case with the ignorecase tag option, which
+// also applies to the bool tokens of the field. For a list, oneOf is called for
+// every value of the list.
+func oneOf(src string, fc *FieldContext) (string, error) {
+	list, ok := fc.Options.Get("oneof")
+	if !ok {
+		return src, nil
+	}
+	allowed := strings.Split(list, "|")
+	for _, name := range allowed {
+		if name == src {
+			return name, nil
+		}
+	}
+	if fc.Options.Has("ignorecase") {
+		for _, name := range allowed {
+			if strings.EqualFold(name, src) {
+				return name, nil
+			}
+		}
+	}
+	return "", notOneOfError(src, allowed)
+}
+
+func notOneOfError(src string, allowed []string) error {
+	return fmt.Errorf("%w: %q is not one of %s", ErrNotAllowed, src, strings.Join(allowed, ", "))
+}
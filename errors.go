@@ -2,6 +2,7 @@ package csvdecoder
 
 import (
 	"errors"
+	"fmt"
 )
 
 var (
@@ -9,7 +10,60 @@ var (
 	ErrScanTargetsNotMatch = errors.New("the number of scan targets does not match the number of csv fields")
 	ErrReadingOccurred     = errors.New("can't continue after a reading error")
 	ErrNextNotCalled       = errors.New("scan called without calling Next")
+	ErrNoHeaders           = errors.New("decoding into a struct requires the header line") // ErrNoHeaders is thrown by Decode if the headers were not read.
+	ErrNotStruct           = errors.New("destination is not a pointer to a struct")
+	ErrInvalidTag          = errors.New("invalid struct tag")
+	ErrAmbiguousColumn     = errors.New("ambiguous column match")
+	ErrNotAllowed          = errors.New("value not allowed") // ErrNotAllowed is wrapped by the errors of the values outside of an enum vocabulary.
+	ErrValidation          = errors.New("validation failed") // ErrValidation is wrapped by the errors of the validation rules of the struct tags and of the schema constraints.
+	ErrInvalidSchema       = errors.New("invalid schema")
 
-	errNilPtr = errors.New("destination is a nil pointer")
-	errNotPtr = errors.New("destination not a pointer")
+	errNilPtr      = errors.New("destination is a nil pointer")
+	errNotPtr      = errors.New("destination not a pointer")
+	errInvalidJSON = errors.New("invalid JSON value")
 )
+
+// FieldError is returned by Scan and Decode when a field of the current
+// record can't be decoded. It records the position of the field. The errors
+// returned by Scan keep their message, "scan error on value index" followed by the index.
+type FieldError struct {
+	Row    int    // the number of the record in the file, starting at 1
+	Index  int    // the index of the field in the record, -1 if the value spans several fields or the error concerns the whole record
+	Column string // the name of the column, or the name of the struct field if it spans several columns. It is empty if the header was not read or the error concerns the whole record.
+	Err    error
+
+	scan bool // the error is returned by Scan
+}
+
+func (e *FieldError) Error() string {
+	if e.scan {
+		return fmt.Sprintf("scan error on value index %d: %v", e.Index, e.Err)
+	}
+	if e.Column == "" && e.Index < 0 {
+		return fmt.Sprintf("decode error on row %d: %v", e.Row, e.Err)
+	}
+	if e.Column == "" {
+		return fmt.Sprintf("decode error on row %d, value index %d: %v", e.Row, e.Index, e.Err)
+	}
+	return fmt.Sprintf("decode error on row %d, column %q: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// JSONError is returned when a field can't be decoded from JSON.
+// It records the location of the error in the JSON value.
+type JSONError struct {
+	Path   string // the path of the JSON value that failed, e.g. $.location.lat
+	Offset int64  // the offset in the field after which the error occurred
+	Err    error
+}
+
+func (e *JSONError) Error() string {
+	return fmt.Sprintf("JSON error at %s (offset %d): %v", e.Path, e.Offset, e.Err)
+}
+
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,349 @@
+package csvdecoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateOptions configures the Go code written by GenerateStruct.
+type GenerateOptions struct {
+	Package  string // the name of the package of the generated file. Default value is main.
+	TypeName string // the name of the struct type. Default value is Record.
+}
+
+// commonInitialisms are the words written in upper case in the Go field names, e.g. ID in OrderID.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "CSV": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "QPS": true, "RAM": true, "RPC": true, "SKU": true, "SLA": true, "SMTP": true,
+	"SQL": true, "SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true, "UI": true,
+	"UID": true, "UUID": true, "URI": true, "URL": true, "UTF8": true, "VAT": true, "VM": true,
+	"XML": true,
+}
+
+// GenerateStruct writes the Go source of a struct type decoding with Decode the rows
+// of a file described by the schema s, e.g. a schema returned by Infer. The fields are
+// named after the columns in Go style and tagged with the name of their column, the
+// title of the schema field as alias, the layout of the dates and times, the predefined
+// number format of the numbers, the missing values of the optional fields as null tokens
+// and the required, enum, length, pattern and number bound constraints. The numbers
+// read by no predefined number format and the dates and times whose layout contains
+// a comma are kept as strings.
+// The fields that may be missing are pointers, except the strings, slices and maps.
+//
+// The source is formatted with gofmt. Decode reads the header of the file, so the
+// decoder must be configured with IgnoreHeaders.
+func GenerateStruct(w io.Writer, s *Schema, opts GenerateOptions) error {
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = "Record"
+	}
+	if !token.IsIdentifier(opts.Package) || !token.IsIdentifier(opts.TypeName) {
+		return fmt.Errorf("invalid package %q or type name %q", opts.Package, opts.TypeName)
+	}
+
+	primaryKey := make(map[string]bool, len(s.PrimaryKey))
+	for _, name := range s.PrimaryKey {
+		primaryKey[name] = true
+	}
+
+	var fields bytes.Buffer
+	names := make(map[string]bool, len(s.Fields))
+	imports := make(map[string]bool)
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		if f.Name == "" || strings.ContainsAny(f.Name, ",|") || f.Name == "-" {
+			return fmt.Errorf("%w: column %q can't be named in a struct tag", ErrInvalidTag, f.Name)
+		}
+		name := goName(f.Name, i)
+		for n := 2; names[name]; n++ {
+			name = goName(f.Name, i) + strconv.Itoa(n)
+		}
+		names[name] = true
+
+		typ, options, pkg := f.goField(s.MissingValues, primaryKey[f.Name])
+		if pkg != "" {
+			imports[pkg] = true
+		}
+		tag := "csv:" + strconv.Quote(strings.Join(append([]string{f.Name}, options...), ","))
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(f.Description), "\n") {
+			if line != "" {
+				fmt.Fprintf(&fields, "\t// %s\n", strings.TrimSpace(line))
+			}
+		}
+		fmt.Fprintf(&fields, "\t%s %s %s\n", name, typ, tag)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "package %s\n\n", opts.Package)
+	if len(imports) == 1 {
+		for pkg := range imports {
+			fmt.Fprintf(&src, "import %q\n\n", pkg)
+		}
+	} else if len(imports) > 1 {
+		pkgs := make([]string, 0, len(imports))
+		for pkg := range imports {
+			pkgs = append(pkgs, strconv.Quote(pkg))
+		}
+		sort.Strings(pkgs)
+		fmt.Fprintf(&src, "import (\n%s\n)\n\n", strings.Join(pkgs, "\n"))
+	}
+	fmt.Fprintf(&src, "// %s is decoded from a row of a CSV file by csvdecoder.Decoder.Decode.\n", opts.TypeName)
+	fmt.Fprintf(&src, "type %s struct {\n%s}\n", opts.TypeName, fields.Bytes())
+
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("could not format the generated code: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// goField returns the Go type of the field f, the options of its struct tag and the package
+// imported by the type, if any. missing are the missing values of the schema.
+func (f *SchemaField) goField(missing []string, primaryKey bool) (string, []string, string) {
+	var typ, pkg string
+	var options []string
+	pointer := true
+	switch f.typ() {
+	case TypeInteger, TypeNumber:
+		typ = "int64"
+		if f.typ() == TypeNumber {
+			typ = "float64"
+		}
+		if name, ok := f.predefinedNumberFormat(); !ok {
+			// the number format can't be named in a tag, the field is kept as written
+			typ, pointer = "string", false
+		} else if name != "" {
+			options = append(options, "numfmt="+name)
+		}
+	case TypeYear:
+		typ = "int"
+	case TypeBoolean:
+		typ = "bool"
+		if f.TrueValues != nil {
+			options = append(options, "true="+strings.Join(f.TrueValues, "|"))
+		}
+		if f.FalseValues != nil {
+			options = append(options, "false="+strings.Join(f.FalseValues, "|"))
+		}
+	case TypeDate, TypeTime, TypeDatetime, TypeYearMonth:
+		typ, pkg = "time.Time", "time"
+		layout := defaultTimeFormats[f.typ()]
+		if f.Format != "" && f.Format != "default" {
+			layout = ""
+			if f.Format != "any" {
+				layout, _ = strptimeLayout(f.Format)
+			}
+		}
+		switch {
+		case strings.Contains(layout, ","):
+			// the layout can't be written in a tag, the field is kept as written
+			typ, pkg, pointer = "string", "", false
+		case layout != "":
+			options = append(options, "layout="+layout)
+		}
+	case TypeDuration:
+		typ, pkg = "time.Duration", "time"
+	case TypeObject:
+		typ, pointer = "map[string]interface{}", false
+		options = append(options, "json")
+	case TypeArray:
+		typ, pointer = "[]interface{}", false
+		options = append(options, "json")
+	default:
+		typ, pointer = "string", false
+		switch f.Format {
+		case "email":
+			options = append(options, "email")
+		case "binary":
+			typ = "[]byte"
+			options = append(options, "base64")
+		}
+	}
+
+	c := f.Constraints
+	if c == nil {
+		c = &Constraints{}
+	}
+	required := c.Required || primaryKey
+	if required {
+		options = append(options, "required")
+	} else if pointer {
+		typ = "*" + typ
+	}
+	if c.Enum != nil {
+		values := make([]string, len(c.Enum))
+		listable := true
+		for i, raw := range c.Enum {
+			values[i] = constraintValue(raw)
+			listable = listable && !strings.Contains(values[i], "|")
+		}
+		if listable {
+			options = append(options, "oneof="+strings.Join(values, "|"))
+		}
+	}
+	switch {
+	case typ == "string" && f.typ() == TypeString, typ == "[]interface{}", typ == "map[string]interface{}":
+		if c.MinLength != nil && c.MaxLength != nil && *c.MinLength == *c.MaxLength {
+			options = append(options, "len="+strconv.Itoa(*c.MinLength))
+			break
+		}
+		if c.MinLength != nil {
+			options = append(options, "min="+strconv.Itoa(*c.MinLength))
+		}
+		if c.MaxLength != nil {
+			options = append(options, "max="+strconv.Itoa(*c.MaxLength))
+		}
+	case strings.TrimPrefix(typ, "*") != "string":
+		options = append(options, f.boundOptions(c)...)
+	}
+	if f.Title != "" && f.Title != f.Name && !strings.Contains(f.Title, "|") {
+		options = append(options, "alias="+f.Title)
+	}
+
+	if f.MissingValues != nil {
+		missing = f.MissingValues
+	}
+	var nulls []string
+	for _, v := range missing {
+		if v != "" {
+			nulls = append(nulls, v)
+		}
+	}
+	if len(nulls) > 0 && !required {
+		options = append(options, "null="+strings.Join(nulls, "|"))
+	}
+
+	// the options whose values contain a separator can't be written in a tag
+	kept := options[:0]
+	for _, opt := range options {
+		if i := strings.IndexByte(opt, '='); i < 0 || !strings.Contains(opt[i+1:], ",") {
+			kept = append(kept, opt)
+		}
+	}
+	if c.Pattern != "" {
+		// the regex option takes the rest of the tag, commas included
+		kept = append(kept, "regex=^(?:"+c.Pattern+")$")
+	}
+	return typ, kept, pkg
+}
+
+// predefinedNumberFormat returns the name of the predefined number format reading the
+// numbers of the field, or "" if they are read without format. It returns false if
+// no predefined format reads them.
+func (f *SchemaField) predefinedNumberFormat() (string, bool) {
+	nf, err := f.numberFormat()
+	if err != nil {
+		return "", false
+	}
+	if nf.decimalSeparator() == '.' && nf.GroupSeparator == 0 {
+		return "", true
+	}
+	for _, name := range []string{"en", "de", "fr", "ch"} {
+		p := predefinedNumberFormats[name]
+		if p.DecimalSeparator == nf.decimalSeparator() && (nf.GroupSeparator == 0 || p.GroupSeparator == nf.GroupSeparator) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// boundOptions returns the min and max options of the minimum and maximum of a number field.
+// The bounds of the dates, times and durations can't be written as validation rules.
+func (f *SchemaField) boundOptions(c *Constraints) []string {
+	parse, err := f.parser(Config{})
+	if err != nil {
+		return nil
+	}
+	var options []string
+	for _, b := range []struct {
+		raw  json.RawMessage
+		name string
+	}{
+		{c.Minimum, "min"},
+		{c.Maximum, "max"},
+	} {
+		if b.raw == nil {
+			continue
+		}
+		v, err := parse(constraintValue(b.raw))
+		if err != nil {
+			continue
+		}
+		switch n := v.(type) {
+		case int64:
+			options = append(options, b.name+"="+strconv.FormatInt(n, 10))
+		case float64:
+			options = append(options, b.name+"="+strconv.FormatFloat(n, 'g', -1, 64))
+		}
+	}
+	return options
+}
+
+// goName returns the exported Go name of the column, e.g. OrderID for order_id.
+// i is the index of the column, naming the columns without letters.
+func goName(column string, i int) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+	runes := []rune(column)
+	for j, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if j > 0 && unicode.IsUpper(r) {
+			// a new word starts at an upper case letter following a lower case letter or a
+			// digit, or followed by a lower case letter in a sequence of upper case letters
+			prev := runes[j-1]
+			next := j+1 < len(runes) && unicode.IsLower(runes[j+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next {
+				flush()
+			}
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		upper := strings.ToUpper(w)
+		if commonInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		rs := []rune(strings.ToLower(w))
+		rs[0] = unicode.ToUpper(rs[0])
+		b.WriteString(string(rs))
+	}
+	name := b.String()
+	if name == "" {
+		return "Field" + strconv.Itoa(i+1)
+	}
+	if !token.IsExported(name) {
+		return "Field" + name
+	}
+	return name
+}
@@ -0,0 +1,194 @@
+package csvdecoder
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+const generateSample = `Order ID,customerEmail,Total Amount,created_at,status,note,paid
+1,a@example.com,9.5,2020-01-31 10:00,open,,yes
+2,b@example.com,10,2020-02-01 11:30,closed,NA,no
+3,c@example.com,3,2020-02-03 11:30,open,late,yes
+4,d@example.com,,2020-02-04 08:00,open,,yes
+`
+
+const generateExpected = "package orders\n" +
+	"\n" +
+	"import \"time\"\n" +
+	"\n" +
+	"// Order is decoded from a row of a CSV file by csvdecoder.Decoder.Decode.\n" +
+	"type Order struct {\n" +
+	"\tOrderID       int64     `csv:\"Order ID,required\"`\n" +
+	"\tCustomerEmail string    `csv:\"customerEmail,required\"`\n" +
+	"\tTotalAmount   *float64  `csv:\"Total Amount,null=NA\"`\n" +
+	"\tCreatedAt     time.Time `csv:\"created_at,layout=2006-01-02 15:04,required\"`\n" +
+	"\tStatus        string    `csv:\"status,required,oneof=closed|open\"`\n" +
+	"\tNote          string    `csv:\"note,null=NA\"`\n" +
+	"\tPaid          bool      `csv:\"paid,true=yes|Yes|YES|y|Y,false=no|No|NO|n|N,required\"`\n" +
+	"}\n"
+
+// generatedOrder is the struct generated from generateSample.
+type generatedOrder struct {
+	OrderID       int64     `csv:"Order ID,required"`
+	CustomerEmail string    `csv:"customerEmail,required"`
+	TotalAmount   *float64  `csv:"Total Amount,null=NA"`
+	CreatedAt     time.Time `csv:"created_at,layout=2006-01-02 15:04,required"`
+	Status        string    `csv:"status,required,oneof=closed|open"`
+	Note          string    `csv:"note,null=NA"`
+	Paid          bool      `csv:"paid,true=yes|Yes|YES|y|Y,false=no|No|NO|n|N,required"`
+}
+
+func TestGenerateStruct(t *testing.T) {
+	s, err := Infer(strings.NewReader(generateSample), InferOptions{
+		Config:        Config{IgnoreHeaders: true},
+		MissingValues: []string{"", "NA"},
+	})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateStruct(&buf, s, GenerateOptions{Package: "orders", TypeName: "Order"}); err != nil {
+		t.Fatalf("could not generate the struct: %s", err)
+	}
+	if buf.String() != generateExpected {
+		t.Errorf("expected\n%s\ngot\n%s", generateExpected, buf.String())
+	}
+
+	// the generated struct decodes the sample
+	d, err := NewWithConfig(strings.NewReader(generateSample), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	var got []generatedOrder
+	for d.Next() {
+		var o generatedOrder
+		if err := d.Decode(&o); err != nil {
+			t.Fatalf("could not decode: %s", err)
+		}
+		got = append(got, o)
+	}
+	if err := d.Err(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(got) != 4 || got[1].TotalAmount == nil || *got[1].TotalAmount != 10 || got[3].TotalAmount != nil ||
+		got[1].Note != "" || got[2].Note != "late" || got[1].Paid || !got[0].CreatedAt.Equal(time.Date(2020, 1, 31, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected orders %+v", got)
+	}
+}
+
+func TestGenerateStructSchema(t *testing.T) {
+	s, err := ReadSchema(strings.NewReader(`{
+  "fields": [
+    {"name": "id", "type": "integer", "description": "The identifier\nof the user."},
+    {"name": "email", "title": "E-Mail", "format": "email"},
+    {"name": "born", "type": "date", "format": "%b %d, %Y"},
+    {"name": "tags", "type": "array"},
+    {"name": "Id", "type": "duration"},
+    {"name": "2fa", "type": "boolean", "missingValues": ["-"]},
+    {"name": "code", "constraints": {"pattern": "[A-Z]{2,3}", "minLength": 2, "maxLength": 3}},
+    {"name": "zip", "constraints": {"minLength": 5, "maxLength": 5}},
+    {"name": "score", "type": "number", "decimalChar": ",", "groupChar": ".", "constraints": {"minimum": 0, "maximum": "1.000"}},
+    {"name": "ratio", "type": "number", "decimalChar": ",", "groupChar": "'"}
+  ],
+  "primaryKey": "id"
+}`))
+	if err != nil {
+		t.Fatalf("could not read the schema: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateStruct(&buf, s, GenerateOptions{}); err != nil {
+		t.Fatalf("could not generate the struct: %s", err)
+	}
+	for _, line := range []string{
+		"package main\n",
+		"type Record struct {\n",
+		"\t// The identifier\n\t// of the user.\n\tID int64 `csv:\"id,required\"`\n",
+		"\tEmail string `csv:\"email,email,alias=E-Mail\"`\n",
+		"\tBorn string `csv:\"born\"`\n",
+		"\tTags []interface{} `csv:\"tags,json\"`\n",
+		"\tID2 *time.Duration `csv:\"Id\"`\n",
+		"\tField2fa *bool `csv:\"2fa,null=-\"`\n",
+		"\tCode string `csv:\"code,min=2,max=3,regex=^(?:[A-Z]{2,3})$\"`\n",
+		"\tZip string `csv:\"zip,len=5\"`\n",
+		"\tScore *float64 `csv:\"score,numfmt=de,min=0,max=1000\"`\n",
+		"\tRatio string `csv:\"ratio\"`\n",
+	} {
+		// the alignment of the fields is ignored
+		if !strings.Contains(strings.Join(strings.Fields(buf.String()), " "), strings.Join(strings.Fields(line), " ")) {
+			t.Errorf("expected %q in\n%s", line, buf.String())
+		}
+	}
+
+	s.Fields[0].Name = "a,b"
+	if err := GenerateStruct(&buf, s, GenerateOptions{}); !errors.Is(err, ErrInvalidTag) {
+		t.Errorf("expected ErrInvalidTag, got %v", err)
+	}
+}
+
+func TestGenerateStructNumberFormat(t *testing.T) {
+	const sample = "amount;count\n1.234,5;1.000\n2,5;7\n"
+	s, err := Infer(strings.NewReader(sample), InferOptions{
+		Config: Config{IgnoreHeaders: true, Comma: ';', NumberFormat: &NumberFormat{DecimalSeparator: ',', GroupSeparator: '.'}},
+	})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := GenerateStruct(&buf, s, GenerateOptions{}); err != nil {
+		t.Fatalf("could not generate the struct: %s", err)
+	}
+	for _, line := range []string{
+		"\tAmount float64 `csv:\"amount,numfmt=de,required\"`\n",
+		"\tCount int64 `csv:\"count,numfmt=de,required\"`\n",
+	} {
+		if !strings.Contains(strings.Join(strings.Fields(buf.String()), " "), strings.Join(strings.Fields(line), " ")) {
+			t.Errorf("expected %q in\n%s", line, buf.String())
+		}
+	}
+
+	// the generated struct decodes the sample without number format in the configuration
+	type record struct {
+		Amount float64 `csv:"amount,numfmt=de,required"`
+		Count  int64   `csv:"count,numfmt=de,required"`
+	}
+	d, err := NewWithConfig(strings.NewReader(sample), Config{IgnoreHeaders: true, Comma: ';'})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	var got []record
+	for d.Next() {
+		var r record
+		if err := d.Decode(&r); err != nil {
+			t.Fatalf("could not decode: %s", err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != 2 || got[0].Amount != 1234.5 || got[0].Count != 1000 || got[1].Amount != 2.5 {
+		t.Errorf("unexpected records %+v", got)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for column, expected := range map[string]string{
+		"order_id":       "OrderID",
+		"Order ID":       "OrderID",
+		"customerEmail":  "CustomerEmail",
+		"HTTPStatusCode": "HTTPStatusCode",
+		"api-url":        "APIURL",
+		"ZIP":            "Zip",
+		"address2":       "Address2",
+		"2020":           "Field2020",
+		"--":             "Field4",
+		"größe":          "Größe",
+	} {
+		if got := goName(column, 3); got != expected {
+			t.Errorf("expected %s for %q, got %s", expected, column, got)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package csvdecoder
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// groupPlaceholder is the placeholder for the group number
+// in the column templates of the pattern tag option.
+const groupPlaceholder = "{n}"
+
+// groupMapping describes how a slice field is decoded from numbered groups
+// of columns, e.g. sku_1,qty_1,sku_2,qty_2.
+type groupMapping struct {
+	names   []string       // the names of the columns of a group, i.e. the templates without the number
+	groups  [][]int        // for each group, the indexes of its columns in the header. -1 if a column is missing
+	element *structMapping // the mapping of the slice elements to the group columns. nil if the elements are not structs
+}
+
+// matchGroups sets the groups of the slice field f of type t from the templates listed
+// in pattern, separated by '|'. The groups are ordered by their number.
+func (b *mappingBuilder) matchGroups(f *fieldMapping, t reflect.Type, prefix, pattern string) error {
+	if t.Kind() != reflect.Slice {
+		return fmt.Errorf("%w: field %s: pattern requires a slice, got %s", ErrInvalidTag, f.path, t)
+	}
+
+	templates := strings.Split(pattern, "|")
+	g := &groupMapping{
+		names: make([]string, len(templates)),
+	}
+	expressions := make([]*regexp.Regexp, len(templates))
+	for i, tpl := range templates {
+		if strings.Count(tpl, groupPlaceholder) != 1 {
+			return fmt.Errorf("%w: field %s: column template %q must contain %s once", ErrInvalidTag, f.path, tpl, groupPlaceholder)
+		}
+		g.names[i] = strings.Trim(strings.Replace(tpl, groupPlaceholder, "", 1), "_-. ")
+		expressions[i] = regexp.MustCompile("(?i)^" + strings.Replace(
+			regexp.QuoteMeta(prefix+tpl),
+			regexp.QuoteMeta(groupPlaceholder),
+			`(\d+)`,
+			1,
+		) + "$")
+	}
+
+	byNumber := make(map[int][]int)
+	for idx, column := range b.header {
+		for i, re := range expressions {
+			match := re.FindStringSubmatch(column)
+			if match == nil {
+				continue
+			}
+			n, err := strconv.Atoi(match[1])
+			if err != nil {
+				continue
+			}
+			cols, ok := byNumber[n]
+			if !ok {
+				cols = []int{}
+				for range templates {
+					cols = append(cols, -1)
+				}
+				byNumber[n] = cols
+			}
+			if cols[i] >= 0 {
+				return fmt.Errorf("field %s: %w: columns %q and %q both match %q",
+					f.path, ErrAmbiguousColumn, b.header[cols[i]], column, prefix+templates[i])
+			}
+			cols[i] = idx
+		}
+	}
+
+	numbers := make([]int, 0, len(byNumber))
+	for n := range byNumber {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		g.groups = append(g.groups, byNumber[n])
+		for _, idx := range byNumber[n] {
+			if idx >= 0 {
+				f.columns = append(f.columns, idx)
+			}
+		}
+	}
+
+	elem := t.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	switch {
+	case elem.Kind() == reflect.Struct && !b.isDecodable(t.Elem()):
+		m, err := newStructMapping(elem, g.names, b.config)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.path, err)
+		}
+		g.element = m
+	case len(templates) != 1:
+		return fmt.Errorf("%w: field %s: a pattern with several columns requires a slice of structs", ErrInvalidTag, f.path)
+	}
+
+	f.group = g
+	return nil
+}
+
+// decodeGroups copies the groups of columns into the slice fv,
+// stopping at the first group with only empty values.
+func (f fieldMapping) decodeGroups(fv reflect.Value, row Row) error {
+	g := f.group
+	s := reflect.MakeSlice(fv.Type(), 0, len(g.groups))
+	for _, cols := range g.groups {
+		values := make([]string, len(cols))
+		empty := true
+		for i, idx := range cols {
+			if idx >= 0 {
+				values[i] = row.Index(idx)
+			}
+			if values[i] != "" {
+				empty = false
+			}
+		}
+		if empty {
+			break
+		}
+
+		ev := reflect.New(fv.Type().Elem()).Elem()
+		if g.element == nil {
+			fc := row.fieldContext(cols[0])
+			fc.Options = f.options
+			if err := convertAssignValue(ev.Addr().Interface(), values[0], &fc); err != nil {
+				return err
+			}
+			s = reflect.Append(s, ev)
+			continue
+		}
+
+		target := ev
+		if target.Kind() == reflect.Ptr {
+			target.Set(reflect.New(target.Type().Elem()))
+			target = target.Elem()
+		}
+		groupRow := Row{
+			number: row.number,
+			header: g.names,
+			values: values,
+			config: row.config,
+		}
+		if err := g.element.decode(target, groupRow); err != nil {
+			// position the error at the column of the file
+			var fieldErr *FieldError
+			if errors.As(err, &fieldErr) && fieldErr.Index >= 0 {
+				fieldErr.Index = cols[fieldErr.Index]
+				if fieldErr.Index >= 0 {
+					fieldErr.Column = row.header[fieldErr.Index]
+				}
+			}
+			return err
+		}
+		s = reflect.Append(s, ev)
+	}
+	fv.Set(s)
+	return nil
+}
@@ -0,0 +1,64 @@
+package csvdecoder
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// hyperLogLogPrecision is the number of bits of the hashes indexing the registers of a
+// hyperLogLog. The 2^14 registers give a standard error of about 0.8%.
+const hyperLogLogPrecision = 14
+
+// hyperLogLog estimates the number of distinct strings added to it in constant memory,
+// see Flajolet et al., HyperLogLog: the analysis of a near-optimal cardinality estimation algorithm.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hyperLogLogPrecision)}
+}
+
+// add adds the string s to the estimated set.
+func (h *hyperLogLog) add(s string) {
+	x := hash64(s)
+	i := x >> (64 - hyperLogLogPrecision)
+	// the rank of the first set bit in the remaining bits, the index bits being shifted out
+	rank := uint8(bits.LeadingZeros64(x<<hyperLogLogPrecision|1<<(hyperLogLogPrecision-1))) + 1
+	if rank > h.registers[i] {
+		h.registers[i] = rank
+	}
+}
+
+// count returns the estimated number of distinct strings added.
+func (h *hyperLogLog) count() int {
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	estimate := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		// linear counting is more accurate for the small cardinalities
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int(estimate + 0.5)
+}
+
+// hash64 returns the 64-bit FNV-1a hash of s, mixed with the finalizer of splitmix64
+// so that every bit of the hash depends on every byte of s.
+func hash64(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,110 @@
+package csvdecoder
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// InferType is a type that can be inferred for a value decoded into an interface{}.
+type InferType int
+
+const (
+	InferInt    InferType = iota + 1 // an int64, for integers without leading zeros
+	InferFloat                       // a float64, for decimal numbers without leading zeros, e.g. not NaN or 0x1p-2
+	InferBool                        // a bool, for true and false in any case or the tokens of the bool format
+	InferTime                        // a time.Time, for values matching one of the time layouts
+	InferString                      // a string. It matches any value.
+)
+
+// defaultInferTypes are the types tried in order if none are configured.
+var defaultInferTypes = []InferType{InferInt, InferFloat, InferBool, InferTime}
+
+// defaultTimeLayouts are the layouts tried in order to infer a time if none are configured.
+var defaultTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// plainFloat matches the decimal floats inferred as float64, e.g. 1.5, .5 or 1e6.
+// The strconv package also parses NaN, infinities, hexadecimal floats and underscores.
+var plainFloat = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][+-]?[0-9]+)?$`)
+
+// inferValue returns the value of src as the first type of the configuration
+// it can be parsed as. It returns src as a string if no type matches.
+func inferValue(src string, config *Config) interface{} {
+	types := config.InferTypes
+	if types == nil {
+		types = defaultInferTypes
+	}
+
+	for _, t := range types {
+		switch t {
+		case InferInt:
+			num, percent, err := normalizeNumber(src, config.NumberFormat)
+			if err != nil || percent || hasLeadingZero(num) {
+				continue
+			}
+			if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+				return i
+			}
+		case InferFloat:
+			num, percent, err := normalizeNumber(src, config.NumberFormat)
+			if err != nil || hasLeadingZero(num) || !plainFloat.MatchString(num) {
+				continue
+			}
+			if f, err := strconv.ParseFloat(num, 64); err == nil {
+				if percent {
+					f /= 100
+				}
+				return f
+			}
+		case InferBool:
+			if format := config.BoolFormat; format != nil {
+				if format.match(src, format.True) {
+					return true
+				}
+				if format.match(src, format.False) {
+					return false
+				}
+				continue
+			}
+			switch strings.ToLower(src) {
+			case "true":
+				return true
+			case "false":
+				return false
+			}
+		case InferTime:
+			if tv, ok := inferTime(src, config.TimeLayouts); ok {
+				return tv
+			}
+		case InferString:
+			return src
+		}
+	}
+	return src
+}
+
+// hasLeadingZero reports whether the number in src starts with a zero followed by a digit.
+// Such values are usually identifiers, e.g. zip codes, and are kept as strings.
+func hasLeadingZero(src string) bool {
+	digits := strings.TrimLeft(src, "+-")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] >= '0' && digits[1] <= '9'
+}
+
+// inferTime parses src with the first matching layout.
+func inferTime(src string, layouts []string) (time.Time, bool) {
+	if layouts == nil {
+		layouts = defaultTimeLayouts
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, src); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,136 @@
+package csvdecoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// IntFormat describes the syntaxes accepted for the integers in the CSV fields,
+// in addition to the decimal integers. It is used when decoding into the int and uint types.
+type IntFormat struct {
+	BasePrefix bool // if set to true, the integers can have a 0x, 0o or 0b base prefix and underscores between digits, like Go literals
+	FromFloat  bool // if set to true, the integers can be written as decimal floats, e.g. 3.0 or 1e6, as long as they have no fractional part
+}
+
+// decimalWithUnderscores matches the decimal integers with underscores between digits, e.g. 1_000.
+var decimalWithUnderscores = regexp.MustCompile(`^[+-]?[0-9]+(_[0-9]+)*$`)
+
+// decimalFloat matches the floats accepted as integers by the FromFloat format, e.g. 3.0 or 1e6.
+var decimalFloat = regexp.MustCompile(`^[+-]?[0-9]+(\.[0-9]*)?([eE][+-]?[0-9]+)?$`)
+
+// intFormat returns the integer format of the field. The baseprefix and
+// fromfloat tag options enable the syntaxes of the format.
+func intFormat(fc *FieldContext) IntFormat {
+	format := fc.Config.IntFormat
+	format.BasePrefix = format.BasePrefix || fc.Options.Has("baseprefix")
+	format.FromFloat = format.FromFloat || fc.Options.Has("fromfloat")
+	return format
+}
+
+// parseInt returns the value of the integer in s, which must fit into the signed integer type t.
+func parseInt(s string, t reflect.Type, format IntFormat) (int64, error) {
+	if !format.BasePrefix && !format.FromFloat {
+		i64, err := strconv.ParseInt(s, 10, t.Bits())
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, newRangeError(s, t, err)
+		}
+		return i64, err
+	}
+
+	i, err := parseBigInteger(s, format)
+	if err != nil {
+		return 0, err
+	}
+	if !i.IsInt64() || i.Int64() != i.Int64()<<(64-t.Bits())>>(64-t.Bits()) {
+		return 0, newRangeError(s, t, strconv.ErrRange)
+	}
+	return i.Int64(), nil
+}
+
+// parseUint returns the value of the integer in s, which must fit into the unsigned integer type t.
+func parseUint(s string, t reflect.Type, format IntFormat) (uint64, error) {
+	if !format.BasePrefix && !format.FromFloat {
+		u64, err := strconv.ParseUint(s, 10, t.Bits())
+		if errors.Is(err, strconv.ErrRange) {
+			return 0, newRangeError(s, t, err)
+		}
+		return u64, err
+	}
+
+	i, err := parseBigInteger(s, format)
+	if err != nil {
+		return 0, err
+	}
+	if !i.IsUint64() || (t.Bits() < 64 && i.Uint64() >= 1<<uint(t.Bits())) {
+		return 0, newRangeError(s, t, strconv.ErrRange)
+	}
+	return i.Uint64(), nil
+}
+
+// parseBigInteger returns the value of the integer in s, accepting the syntaxes of the format.
+// A leading zero without base prefix is not an octal prefix: 010 is 10.
+func parseBigInteger(s string, format IntFormat) (*big.Int, error) {
+	if format.BasePrefix {
+		if hasBasePrefix(s) {
+			if i, ok := new(big.Int).SetString(s, 0); ok {
+				return i, nil
+			}
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		if decimalWithUnderscores.MatchString(s) {
+			s = strings.ReplaceAll(s, "_", "")
+		}
+	}
+
+	if i, ok := new(big.Int).SetString(s, 10); ok {
+		return i, nil
+	}
+	if format.FromFloat && decimalFloat.MatchString(s) {
+		if i := strings.IndexAny(s, "eE"); i >= 0 {
+			if exp, err := strconv.Atoi(s[i+1:]); err != nil || exp > maxDecimalScale || exp < -maxDecimalScale {
+				return nil, fmt.Errorf("invalid integer %q: exponent out of range", s)
+			}
+		}
+		if r, ok := new(big.Rat).SetString(s); ok {
+			if !r.IsInt() {
+				return nil, fmt.Errorf("%q is not an integer: the fractional part would be lost", s)
+			}
+			return r.Num(), nil
+		}
+	}
+	return nil, fmt.Errorf("invalid integer %q", s)
+}
+
+// hasBasePrefix reports whether the integer in s starts with a 0x, 0o or 0b base prefix.
+func hasBasePrefix(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	if len(s) < 2 || s[0] != '0' {
+		return false
+	}
+	switch s[1] {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	}
+	return false
+}
+
+// newRangeError returns an error naming the integer type t and its range.
+func newRangeError(s string, t reflect.Type, err error) error {
+	var min, max string
+	bits := uint(t.Bits())
+	switch t.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		min = "0"
+		max = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bits), big.NewInt(1)).String()
+	default:
+		limit := new(big.Int).Lsh(big.NewInt(1), bits-1)
+		min = new(big.Int).Neg(limit).String()
+		max = new(big.Int).Sub(limit, big.NewInt(1)).String()
+	}
+	return fmt.Errorf("%s is out of the range of %s [%s, %s]: %w", s, t, min, max, err)
+}
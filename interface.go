@@ -13,3 +13,49 @@ package csvdecoder
 type Interface interface {
 	DecodeField(s string) error
 }
+
+// The RecordDecoder type describes the requirements
+// for a type that is decoded from a whole CSV record
+// instead of a single field. It allows a Go value
+// to span several columns.
+//
+// A type implementing it is given the current row
+// when passed as the single target of Scan, as the
+// target of Decode or as the type of a struct field
+// decoded by Decode.
+type RecordDecoder interface {
+	DecodeRecord(row Row) error
+}
+
+// CombineFunc is a function that decodes several CSV fields
+// into a single Go value. dest is a pointer to the value
+// and values holds the fields in the order of the columns
+// listed in the struct tag.
+// See Config.RegisterCombiner.
+type CombineFunc func(dest interface{}, values []string) error
+
+// The ContextInterface type describes the requirements
+// for a type that is decoded from a CSV field and needs
+// to know where the field comes from, e.g. to build
+// better error messages or to behave differently per
+// column. It is preferred over Interface when a type
+// implements both.
+type ContextInterface interface {
+	DecodeFieldContext(fc FieldContext, s string) error
+}
+
+// The Validator type describes the requirements for a
+// struct type checking its own values. Decode calls the
+// Validate method of its target once the row is decoded
+// and the validation rules of the struct tags passed.
+// A returned error is wrapped in a FieldError giving the
+// number of the row.
+type Validator interface {
+	Validate() error
+}
+
+// ConvertFunc is a function that converts a CSV field into a Go value.
+// The returned value must be assignable or convertible to the type of
+// the destination.
+// See Config.RegisterType and Config.RegisterColumn.
+type ConvertFunc func(s string) (interface{}, error)
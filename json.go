@@ -0,0 +1,40 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// convertAssignJSON copies to dv the JSON value in src.
+// The types implementing json.Unmarshaler decode themselves.
+func convertAssignJSON(dv reflect.Value, src string) error {
+	obj := reflect.New(dv.Type())
+	if err := json.Unmarshal([]byte(src), obj.Interface()); err != nil {
+		return fmt.Errorf("could not parse %s as JSON: %w", src, newJSONError(err))
+	}
+	dv.Set(obj.Elem())
+	return nil
+}
+
+// newJSONError returns a JSONError locating the error returned by the JSON decoder.
+func newJSONError(err error) *JSONError {
+	jsonErr := &JSONError{
+		Path: "$",
+		Err:  err,
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		jsonErr.Offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		jsonErr.Offset = typeErr.Offset
+		if typeErr.Field != "" {
+			jsonErr.Path += "." + typeErr.Field
+		}
+	}
+	return jsonErr
+}
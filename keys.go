@@ -0,0 +1,620 @@
+package csvdecoder
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// maxRuns is the number of spill files of a key set above which they are merged into one,
+// bounding the number of open files when the runs are merged.
+const maxRuns = 64
+
+// ViolationKind is the kind of a KeyViolation.
+type ViolationKind int
+
+const (
+	DuplicateKey ViolationKind = iota + 1 // the key was seen on a previous row
+	MissingKey                            // a value of the primary key is missing
+	OrphanKey                             // the foreign key is not in the referenced key set
+)
+
+func (k ViolationKind) String() string {
+	switch k {
+	case DuplicateKey:
+		return "duplicate"
+	case MissingKey:
+		return "missing"
+	case OrphanKey:
+		return "orphan"
+	}
+	return "unknown"
+}
+
+// KeyViolation is a row breaking a key constraint checked by Decoder.CheckKeys.
+// It wraps ErrValidation. The rows are numbered like Row.Number: they are the
+// numbers of the records, which differ from the line numbers after a quoted line
+// break. The lines give the position of the rows in the file.
+type KeyViolation struct {
+	Kind      ViolationKind
+	Row       int      // the number of the row breaking the constraint
+	Line      int      // the line the row breaking the constraint starts on, starting at 1
+	Columns   []string // the columns of the key
+	Values    []string // the values of the key in the row
+	FirstRow  int      // the row the key was first seen on, for a duplicate key. If the keys were spilled to disk, it can be another previous row with the key.
+	FirstLine int      // the line FirstRow starts on, for a duplicate key
+}
+
+func (v *KeyViolation) Error() string {
+	key := fmt.Sprintf("(%s)=(%s)", strings.Join(v.Columns, ", "), strings.Join(v.Values, ", "))
+	switch v.Kind {
+	case DuplicateKey:
+		return fmt.Sprintf("line %d: duplicate key %s, first seen on line %d", v.Line, key, v.FirstLine)
+	case MissingKey:
+		return fmt.Sprintf("line %d: missing value in primary key %s", v.Line, key)
+	}
+	return fmt.Sprintf("line %d: key %s is not in the referenced key set", v.Line, key)
+}
+
+func (v *KeyViolation) Unwrap() error {
+	return ErrValidation
+}
+
+// KeySetOptions configures a KeySet.
+type KeySetOptions struct {
+	MemoryLimit int    // the number of keys kept in memory, above which the keys are spilled to disk. Default value is 0: the keys are always kept in memory.
+	Dir         string // the directory of the spill files. Default value is the directory of os.TempDir.
+}
+
+// KeySet records the values of the key columns of the rows, with the row each key
+// was first seen on. It is filled by Decoder.CheckKeys, as the primary key or a unique
+// key of a file, and can then be referenced by the foreign keys of other files.
+//
+// Above MemoryLimit keys, the keys are written to sorted files on disk and the
+// duplicates are found by merging the files once the rows are read. The spill files
+// are removed by Close.
+type KeySet struct {
+	columns []string
+	keys    map[string]keyPos
+	runs    keyRuns
+	pending bool // keys were added since the set was last finished
+}
+
+// NewKeySet returns an empty key set of the given columns.
+func NewKeySet(columns []string, opts KeySetOptions) *KeySet {
+	return &KeySet{
+		columns: columns,
+		keys:    make(map[string]keyPos),
+		runs:    keyRuns{dir: opts.Dir, limit: opts.MemoryLimit},
+	}
+}
+
+// Columns returns the columns of the keys.
+func (s *KeySet) Columns() []string {
+	return s.columns
+}
+
+// Close removes the spill files of the key set.
+func (s *KeySet) Close() error {
+	s.keys = make(map[string]keyPos)
+	return s.runs.close()
+}
+
+// add records the key seen at pos. It returns the position the key was first seen at
+// if it is already in memory. Above the memory limit, the keys are spilled to disk.
+func (s *KeySet) add(key string, pos keyPos) (keyPos, bool, error) {
+	if first, ok := s.keys[key]; ok {
+		return first, true, nil
+	}
+	s.keys[key] = pos
+	s.pending = true
+	if s.runs.limit > 0 && len(s.keys) > s.runs.limit {
+		return keyPos{}, false, s.spill()
+	}
+	return keyPos{}, false, nil
+}
+
+// spill writes the keys in memory to disk.
+func (s *KeySet) spill() error {
+	for k, pos := range s.keys {
+		s.runs.entries = append(s.runs.entries, keyEntry{key: k, keyPos: pos})
+	}
+	s.keys = make(map[string]keyPos)
+	return s.runs.spill()
+}
+
+// spilled reports whether some keys of the set are on disk.
+func (s *KeySet) spilled() bool {
+	return len(s.runs.files) > 0
+}
+
+// finish merges the keys on disk into a single file of unique keys and calls
+// duplicate for the keys seen on a previous row.
+func (s *KeySet) finish(duplicate func(key string, pos, first keyPos)) error {
+	if !s.spilled() {
+		s.pending = false
+		return nil
+	}
+	if err := s.spill(); err != nil {
+		return err
+	}
+
+	w, err := s.runs.create()
+	if err != nil {
+		return err
+	}
+	var prev keyEntry
+	first := true
+	err = mergeRuns(s.runs.files, func(e keyEntry) error {
+		if !first && e.key == prev.key {
+			duplicate(e.key, e.keyPos, prev.keyPos)
+			return nil
+		}
+		first, prev = false, e
+		return w.write(e)
+	})
+	if cerr := w.close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(w.path)
+		return err
+	}
+	err = s.runs.close()
+	s.runs.files = []string{w.path}
+	if err != nil {
+		return err
+	}
+	s.pending = false
+	return nil
+}
+
+// contains reports whether the key set holds key. The keys must be in memory.
+func (s *KeySet) contains(key string) bool {
+	_, ok := s.keys[key]
+	return ok
+}
+
+// ForeignKey is a key whose values must be in a key set of another file.
+type ForeignKey struct {
+	Columns   []string // the columns of the key in the checked file
+	Reference *KeySet  // the referenced keys, filled by a previous call to CheckKeys
+}
+
+// KeyConstraints are the key constraints checked by Decoder.CheckKeys.
+type KeyConstraints struct {
+	PrimaryKey    *KeySet      // the key identifying a row: it must be unique and its values must not be missing
+	Unique        []*KeySet    // the keys that must be unique. The rows with a missing value in the key are ignored.
+	ForeignKeys   []ForeignKey // the keys that must be in another file. The rows with a missing value in the key are ignored.
+	MissingValues []string     // the values standing for missing values. Default value is the empty string.
+}
+
+// keyCheck is a key checked by CheckKeys, with the indexes of its columns in the header.
+type keyCheck struct {
+	columns []string
+	indexes []int
+	set     *KeySet // the set recording the key, nil for a foreign key
+	ref     *KeySet // the referenced set of a foreign key
+	primary bool
+	probes  *keyRuns // the keys of a foreign key looked up once the rows are read, if the referenced set is on disk
+}
+
+// CheckKeys reads the remaining rows of the decoder and checks the key constraints c.
+// It returns the rows breaking the constraints, ordered by row. The key columns are
+// matched with the header by name.
+//
+// The keys of the primary key and the unique keys are recorded in their key sets, so
+// that the foreign keys of the files read afterwards can reference them. The key sets
+// on disk are checked once all the rows are read.
+func (p *Decoder) CheckKeys(c KeyConstraints) ([]*KeyViolation, error) {
+	if p.header == nil {
+		return nil, fmt.Errorf("%w: the key columns are matched with the header, IgnoreHeaders must be set", ErrScanTargetsNotMatch)
+	}
+	missing := map[string]bool{"": true}
+	if c.MissingValues != nil {
+		missing = make(map[string]bool, len(c.MissingValues))
+		for _, v := range c.MissingValues {
+			missing[v] = true
+		}
+	}
+
+	var checks []*keyCheck
+	addCheck := func(k *keyCheck) error {
+		if len(k.columns) == 0 {
+			return fmt.Errorf("a key requires columns")
+		}
+		for _, name := range k.columns {
+			idx, err := columnIndex(p.header, name)
+			if err != nil {
+				return err
+			}
+			if idx < 0 {
+				return fmt.Errorf("%w: no column %q for key (%s)", ErrScanTargetsNotMatch, name, strings.Join(k.columns, ", "))
+			}
+			k.indexes = append(k.indexes, idx)
+		}
+		checks = append(checks, k)
+		return nil
+	}
+	if c.PrimaryKey != nil {
+		if err := addCheck(&keyCheck{columns: c.PrimaryKey.columns, set: c.PrimaryKey, primary: true}); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range c.Unique {
+		if err := addCheck(&keyCheck{columns: s.columns, set: s}); err != nil {
+			return nil, err
+		}
+	}
+	for _, fk := range c.ForeignKeys {
+		if fk.Reference == nil || len(fk.Columns) != len(fk.Reference.columns) {
+			return nil, fmt.Errorf("foreign key (%s) requires a reference with as many columns", strings.Join(fk.Columns, ", "))
+		}
+		if fk.Reference.pending || fk.Reference == c.PrimaryKey || containsKeySet(c.Unique, fk.Reference) {
+			return nil, fmt.Errorf("foreign key (%s) references a key set that is not complete: the call to CheckKeys filling it must have returned without error", strings.Join(fk.Columns, ", "))
+		}
+		k := &keyCheck{columns: fk.Columns, ref: fk.Reference}
+		if fk.Reference.spilled() {
+			k.probes = &keyRuns{dir: fk.Reference.runs.dir, limit: fk.Reference.runs.limit}
+			defer k.probes.close()
+		}
+		if err := addCheck(k); err != nil {
+			return nil, err
+		}
+	}
+
+	var violations []*KeyViolation
+	violation := func(kind ViolationKind, k *keyCheck, key string, pos, first keyPos) {
+		violations = append(violations, &KeyViolation{
+			Kind:      kind,
+			Row:       pos.row,
+			Line:      pos.line,
+			Columns:   k.columns,
+			Values:    strings.Split(key, "\x00"),
+			FirstRow:  first.row,
+			FirstLine: first.line,
+		})
+	}
+
+	values := make([]string, 0, 4)
+	for p.Next() {
+		row := p.row()
+		line, _ := p.reader.FieldPos(0)
+		pos := keyPos{row: row.Number(), line: line}
+		for _, k := range checks {
+			values = values[:0]
+			hasMissing := false
+			for _, idx := range k.indexes {
+				v := row.Index(idx)
+				hasMissing = hasMissing || missing[v]
+				values = append(values, v)
+			}
+			key := strings.Join(values, "\x00")
+			switch {
+			case hasMissing && k.primary:
+				violation(MissingKey, k, key, pos, keyPos{})
+			case hasMissing:
+			case k.set != nil:
+				first, ok, err := k.set.add(key, pos)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					violation(DuplicateKey, k, key, pos, first)
+				}
+			case k.probes != nil:
+				if err := k.probes.add(keyEntry{key: key, keyPos: pos}); err != nil {
+					return nil, err
+				}
+			case !k.ref.contains(key):
+				violation(OrphanKey, k, key, pos, keyPos{})
+			}
+		}
+	}
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, k := range checks {
+		k := k
+		switch {
+		case k.set != nil:
+			err := k.set.finish(func(key string, pos, first keyPos) {
+				violation(DuplicateKey, k, key, pos, first)
+			})
+			if err != nil {
+				return nil, err
+			}
+		case k.probes != nil:
+			err := k.probes.lookup(k.ref.runs.files[0], func(e keyEntry) {
+				violation(OrphanKey, k, e.key, e.keyPos, keyPos{})
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Row < violations[j].Row
+	})
+	return violations, nil
+}
+
+// containsKeySet reports whether sets contains s.
+func containsKeySet(sets []*KeySet, s *KeySet) bool {
+	for _, set := range sets {
+		if set == s {
+			return true
+		}
+	}
+	return false
+}
+
+// keyPos is the position of the row a key was seen on.
+type keyPos struct {
+	row  int // the number of the row, as returned by Row.Number
+	line int // the line the row starts on
+}
+
+// keyEntry is a key and the position of the row it was seen on.
+type keyEntry struct {
+	key string
+	keyPos
+}
+
+// keyRuns holds key entries in memory up to a limit, then in sorted files on disk.
+type keyRuns struct {
+	dir     string
+	limit   int
+	entries []keyEntry
+	files   []string
+}
+
+// add adds the entry e, spilling the entries in memory to disk above the limit.
+func (r *keyRuns) add(e keyEntry) error {
+	r.entries = append(r.entries, e)
+	if len(r.entries) > r.limit {
+		return r.spill()
+	}
+	return nil
+}
+
+// spill writes the entries in memory to a new file, sorted by key and row.
+// Above maxRuns files, the files are merged into one.
+func (r *keyRuns) spill() error {
+	sort.Slice(r.entries, func(i, j int) bool {
+		a, b := r.entries[i], r.entries[j]
+		return a.key < b.key || a.key == b.key && a.row < b.row
+	})
+	w, err := r.create()
+	if err != nil {
+		return err
+	}
+	for _, e := range r.entries {
+		if err := w.write(e); err != nil {
+			w.close()
+			os.Remove(w.path)
+			return err
+		}
+	}
+	if err := w.close(); err != nil {
+		os.Remove(w.path)
+		return err
+	}
+	r.files = append(r.files, w.path)
+	r.entries = r.entries[:0]
+
+	if len(r.files) < maxRuns {
+		return nil
+	}
+	merged, err := r.create()
+	if err != nil {
+		return err
+	}
+	err = mergeRuns(r.files, merged.write)
+	if cerr := merged.close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(merged.path)
+		return err
+	}
+	err = r.close()
+	r.files = []string{merged.path}
+	return err
+}
+
+// lookup spills the entries in memory and calls orphan for the entries whose
+// key is not in the file of unique sorted keys ref.
+func (r *keyRuns) lookup(ref string, orphan func(e keyEntry)) error {
+	if len(r.entries) > 0 {
+		if err := r.spill(); err != nil {
+			return err
+		}
+	}
+	refs, err := openRun(ref)
+	if err != nil {
+		return err
+	}
+	defer refs.close()
+	return mergeRuns(r.files, func(e keyEntry) error {
+		for !refs.done && refs.cur.key < e.key {
+			if err := refs.next(); err != nil {
+				return err
+			}
+		}
+		if refs.done || refs.cur.key != e.key {
+			orphan(e)
+		}
+		return nil
+	})
+}
+
+// create creates a new spill file.
+func (r *keyRuns) create() (*runWriter, error) {
+	f, err := os.CreateTemp(r.dir, "csvdecoder-keys-*")
+	if err != nil {
+		return nil, fmt.Errorf("could not spill the keys to disk: %w", err)
+	}
+	return &runWriter{f: f, w: bufio.NewWriter(f), path: f.Name()}, nil
+}
+
+// close removes the spill files.
+func (r *keyRuns) close() error {
+	var err error
+	for _, path := range r.files {
+		if rerr := os.Remove(path); rerr != nil && err == nil {
+			err = rerr
+		}
+	}
+	r.files = nil
+	return err
+}
+
+// runWriter writes key entries to a spill file, as the length of the key, the key,
+// the row and the line, the numbers being written as varints.
+type runWriter struct {
+	f    *os.File
+	w    *bufio.Writer
+	path string
+	buf  [binary.MaxVarintLen64]byte
+}
+
+func (w *runWriter) write(e keyEntry) error {
+	n := binary.PutUvarint(w.buf[:], uint64(len(e.key)))
+	if _, err := w.w.Write(w.buf[:n]); err != nil {
+		return err
+	}
+	if _, err := w.w.WriteString(e.key); err != nil {
+		return err
+	}
+	for _, v := range []int{e.row, e.line} {
+		n = binary.PutUvarint(w.buf[:], uint64(v))
+		if _, err := w.w.Write(w.buf[:n]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *runWriter) close() error {
+	err := w.w.Flush()
+	if cerr := w.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// runReader reads the key entries of a spill file.
+type runReader struct {
+	f    *os.File
+	r    *bufio.Reader
+	cur  keyEntry
+	done bool
+}
+
+// openRun opens the spill file at path, positioned on its first entry.
+func openRun(path string) (*runReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &runReader{f: f, r: bufio.NewReader(f)}
+	if err := r.next(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// next reads the next entry into cur, or sets done at the end of the file.
+func (r *runReader) next() error {
+	l, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		r.done = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	key := make([]byte, l)
+	if _, err := io.ReadFull(r.r, key); err != nil {
+		return err
+	}
+	row, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return err
+	}
+	line, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return err
+	}
+	r.cur = keyEntry{key: string(key), keyPos: keyPos{row: int(row), line: int(line)}}
+	return nil
+}
+
+func (r *runReader) close() error {
+	return r.f.Close()
+}
+
+// runHeap orders the spill files being merged by their current entry.
+type runHeap []*runReader
+
+func (h runHeap) Len() int { return len(h) }
+func (h runHeap) Less(i, j int) bool {
+	a, b := h[i].cur, h[j].cur
+	return a.key < b.key || a.key == b.key && a.row < b.row
+}
+func (h runHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *runHeap) Push(x interface{}) { *h = append(*h, x.(*runReader)) }
+func (h *runHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// mergeRuns calls fn with the entries of the spill files in order of key and row.
+func mergeRuns(paths []string, fn func(e keyEntry) error) error {
+	h := make(runHeap, 0, len(paths))
+	defer func() {
+		for _, r := range h {
+			r.close()
+		}
+	}()
+	for _, path := range paths {
+		r, err := openRun(path)
+		if err != nil {
+			return err
+		}
+		if r.done {
+			r.close()
+			continue
+		}
+		h = append(h, r)
+	}
+	heap.Init(&h)
+
+	for len(h) > 0 {
+		r := h[0]
+		if err := fn(r.cur); err != nil {
+			return err
+		}
+		if err := r.next(); err != nil {
+			return err
+		}
+		if r.done {
+			heap.Pop(&h)
+			r.close()
+			continue
+		}
+		heap.Fix(&h, 0)
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package csvdecoder
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCheckKeys(t *testing.T) {
+	customersData := "customer_id,name\n1,Ada\n2,Alan\n1,Grace\n,Edsger\n"
+	ordersData := "order_id,customer_id,line\n10,1,1\n11,3,1\n10,2,2\n12,,1\n11,3,2\n"
+
+	customers := NewKeySet([]string{"customer_id"}, KeySetOptions{})
+	defer customers.Close()
+	d, err := NewWithConfig(strings.NewReader(customersData), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	violations, err := d.CheckKeys(KeyConstraints{PrimaryKey: customers})
+	if err != nil {
+		t.Fatalf("could not check the keys: %s", err)
+	}
+	expected := []*KeyViolation{
+		{Kind: DuplicateKey, Row: 4, Line: 4, Columns: []string{"customer_id"}, Values: []string{"1"}, FirstRow: 2, FirstLine: 2},
+		{Kind: MissingKey, Row: 5, Line: 5, Columns: []string{"customer_id"}, Values: []string{""}},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected %v, got %v", expected, violations)
+	}
+
+	d, err = NewWithConfig(strings.NewReader(ordersData), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	lines := NewKeySet([]string{"order_id", "line"}, KeySetOptions{})
+	violations, err = d.CheckKeys(KeyConstraints{
+		Unique:      []*KeySet{NewKeySet([]string{"order_id"}, KeySetOptions{})},
+		PrimaryKey:  lines,
+		ForeignKeys: []ForeignKey{{Columns: []string{"customer_id"}, Reference: customers}},
+	})
+	if err != nil {
+		t.Fatalf("could not check the keys: %s", err)
+	}
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.Error())
+	}
+	expectedErrors := []string{
+		"line 3: key (customer_id)=(3) is not in the referenced key set",
+		"line 4: duplicate key (order_id)=(10), first seen on line 2",
+		"line 6: duplicate key (order_id)=(11), first seen on line 3",
+		"line 6: key (customer_id)=(3) is not in the referenced key set",
+	}
+	if !reflect.DeepEqual(got, expectedErrors) {
+		t.Errorf("expected %q, got %q", expectedErrors, got)
+	}
+	if !errors.Is(violations[0], ErrValidation) {
+		t.Errorf("expected the violations to wrap ErrValidation")
+	}
+
+	d, err = NewWithConfig(strings.NewReader(ordersData), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	if _, err := d.CheckKeys(KeyConstraints{PrimaryKey: NewKeySet([]string{"id"}, KeySetOptions{})}); !errors.Is(err, ErrScanTargetsNotMatch) {
+		t.Errorf("expected ErrScanTargetsNotMatch, got %v", err)
+	}
+}
+
+func TestCheckKeysLines(t *testing.T) {
+	data := "id,note\n1,\"two\nlines\"\n2,\n1,\"three\n\nlines\"\n1,\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	violations, err := d.CheckKeys(KeyConstraints{PrimaryKey: NewKeySet([]string{"id"}, KeySetOptions{})})
+	if err != nil {
+		t.Fatalf("could not check the keys: %s", err)
+	}
+	expected := []*KeyViolation{
+		{Kind: DuplicateKey, Row: 4, Line: 5, Columns: []string{"id"}, Values: []string{"1"}, FirstRow: 2, FirstLine: 2},
+		{Kind: DuplicateKey, Row: 5, Line: 8, Columns: []string{"id"}, Values: []string{"1"}, FirstRow: 2, FirstLine: 2},
+	}
+	if !reflect.DeepEqual(violations, expected) {
+		t.Errorf("expected %v, got %v", expected, violations)
+	}
+}
+
+func TestCheckKeysSpill(t *testing.T) {
+	var customersData, ordersData strings.Builder
+	customersData.WriteString("id\n")
+	for i := 0; i < 1000; i++ {
+		// every hundredth customer is duplicated
+		fmt.Fprintf(&customersData, "c%d\n", i)
+		if i%100 == 0 {
+			fmt.Fprintf(&customersData, "c%d\n", i)
+		}
+	}
+	ordersData.WriteString("id,customer\n")
+	for i := 0; i < 2000; i++ {
+		// every 250th order references an unknown customer
+		customer := i % 1000
+		if i%250 == 0 {
+			customer += 5000
+		}
+		fmt.Fprintf(&ordersData, "o%d,c%d\n", i%1990, customer)
+	}
+
+	check := func(opts KeySetOptions) ([]*KeyViolation, []*KeyViolation) {
+		customers := NewKeySet([]string{"id"}, opts)
+		defer customers.Close()
+		d, err := NewWithConfig(strings.NewReader(customersData.String()), Config{IgnoreHeaders: true})
+		if err != nil {
+			t.Fatalf("could not create d: %s", err)
+		}
+		customerViolations, err := d.CheckKeys(KeyConstraints{PrimaryKey: customers})
+		if err != nil {
+			t.Fatalf("could not check the customers: %s", err)
+		}
+
+		orders := NewKeySet([]string{"id"}, opts)
+		defer orders.Close()
+		d, err = NewWithConfig(strings.NewReader(ordersData.String()), Config{IgnoreHeaders: true})
+		if err != nil {
+			t.Fatalf("could not create d: %s", err)
+		}
+		orderViolations, err := d.CheckKeys(KeyConstraints{
+			PrimaryKey:  orders,
+			ForeignKeys: []ForeignKey{{Columns: []string{"customer"}, Reference: customers}},
+		})
+		if err != nil {
+			t.Fatalf("could not check the orders: %s", err)
+		}
+		return customerViolations, orderViolations
+	}
+
+	customers, orders := check(KeySetOptions{})
+	if len(customers) != 10 || len(orders) != 18 {
+		t.Fatalf("expected 10 duplicate customers and 8 orphan and 10 duplicate orders, got %d and %d", len(customers), len(orders))
+	}
+
+	dir := t.TempDir()
+	spilledCustomers, spilledOrders := check(KeySetOptions{MemoryLimit: 7, Dir: dir})
+	if !reflect.DeepEqual(spilledCustomers, customers) {
+		t.Errorf("expected %v, got %v", customers, spilledCustomers)
+	}
+	if !reflect.DeepEqual(spilledOrders, orders) {
+		t.Errorf("expected %v, got %v", orders, spilledOrders)
+	}
+	if files, err := os.ReadDir(dir); err != nil || len(files) != 0 {
+		t.Errorf("expected the spill files to be removed, got %v (%v)", files, err)
+	}
+}
+
+func TestCheckKeysIncompleteReference(t *testing.T) {
+	errRead := errors.New("read error")
+	customers := NewKeySet([]string{"customer_id"}, KeySetOptions{MemoryLimit: 1, Dir: t.TempDir()})
+	defer customers.Close()
+	in := io.MultiReader(strings.NewReader("customer_id\n1\n2\n3\n"), iotest.ErrReader(errRead))
+	d, err := NewWithConfig(in, Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	if _, err := d.CheckKeys(KeyConstraints{PrimaryKey: customers}); !errors.Is(err, errRead) {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+
+	d, err = NewWithConfig(strings.NewReader("customer_id\n1\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	fk := ForeignKey{Columns: []string{"customer_id"}, Reference: customers}
+	if _, err := d.CheckKeys(KeyConstraints{ForeignKeys: []ForeignKey{fk}}); err == nil {
+		t.Error("expected an error for a reference that was not completely filled")
+	}
+
+	ids := NewKeySet([]string{"id"}, KeySetOptions{})
+	d, err = NewWithConfig(strings.NewReader("id,parent_id\n1,\n2,1\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	fk = ForeignKey{Columns: []string{"parent_id"}, Reference: ids}
+	if _, err := d.CheckKeys(KeyConstraints{PrimaryKey: ids, ForeignKeys: []ForeignKey{fk}}); err == nil {
+		t.Error("expected an error for a reference filled by the same call")
+	}
+}
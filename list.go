@@ -0,0 +1,151 @@
+package csvdecoder
+
+import (
+	"encoding/csv"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultKeyValueSeparator separates the keys from the values in the lists decoded into maps.
+const defaultKeyValueSeparator = '='
+
+// listSeparators returns the separator of the values and the separator of the keys and values
+// to use when decoding the field into a slice, an array or a map. The boolean is false if the
+// field must be decoded from JSON.
+func listSeparators(fc *FieldContext) (sep, kvSep rune, ok bool, err error) {
+	if fc.Options.Has("json") {
+		return 0, 0, false, nil
+	}
+
+	sep = fc.Config.ListSeparator
+	if s, ok := fc.Options.Get("split"); ok {
+		if sep, err = tagRune("split", s); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	kvSep = fc.Config.KeyValueSeparator
+	if s, ok := fc.Options.Get("kv"); ok {
+		if kvSep, err = tagRune("kv", s); err != nil {
+			return 0, 0, false, err
+		}
+	}
+	if kvSep == 0 {
+		kvSep = defaultKeyValueSeparator
+	}
+	if sep != 0 && sep == kvSep {
+		return 0, 0, false, fmt.Errorf("%w: the list separator and the key value separator are both %q", ErrInvalidTag, sep)
+	}
+	return sep, kvSep, sep != 0, nil
+}
+
+// decodedAsList reports whether a field is decoded into the type t as a list of
+// separated values. The byte slices and arrays and the types with a decoder are
+// decoded as a whole.
+func decodedAsList(t reflect.Type, fc *FieldContext) bool {
+	t = indirectType(t)
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+	case reflect.Map:
+	default:
+		return false
+	}
+	pt := reflect.PtrTo(t)
+	if pt.Implements(contextInterfaceType) || pt.Implements(interfaceType) {
+		return false
+	}
+	_, _, ok, err := listSeparators(fc)
+	return ok && err == nil
+}
+
+// tagRune returns the single character given as value of a tag option.
+func tagRune(option, s string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if size == 0 || size != len(s) || r == utf8.RuneError {
+		return 0, fmt.Errorf("%w: option %s requires a single character, got %q", ErrInvalidTag, option, s)
+	}
+	return r, nil
+}
+
+// splitList splits src into the values separated by sep. A value can be quoted
+// to contain the separator. The spaces around the values are removed.
+func splitList(src string, sep rune) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(src))
+	r.Comma = sep
+	r.LazyQuotes = true
+	r.FieldsPerRecord = -1
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("could not split %q with separator %q: %w", src, sep, err)
+	}
+	var values []string
+	for _, record := range records {
+		for _, v := range record {
+			values = append(values, strings.TrimSpace(v))
+		}
+	}
+	return values, nil
+}
+
+// convertAssignList copies to dv, a slice, an array or a map, the values of the
+// list in src. The elements of a map are given as key and value separated by kvSep.
+func convertAssignList(dv reflect.Value, src string, sep, kvSep rune, fc *FieldContext) error {
+	values, err := splitList(src, sep)
+	if err != nil {
+		return err
+	}
+	// the separators of the tag apply to this list only: the values holding
+	// nested lists are split with the separators of the configuration
+	elemFC := *fc
+	elemFC.Options = fc.Options.without("split", "kv")
+	// the oneof option restricts the values of a map, not its keys
+	keyFC := elemFC
+	keyFC.Options = elemFC.Options.without("oneof")
+
+	switch dv.Kind() {
+	case reflect.Slice:
+		s := reflect.MakeSlice(dv.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := convertAssignValue(s.Index(i).Addr().Interface(), v, &elemFC); err != nil {
+				return fmt.Errorf("list element %d: %w", i, err)
+			}
+		}
+		dv.Set(s)
+	case reflect.Array:
+		if len(values) > dv.Len() {
+			return fmt.Errorf("got %d values for an array of length %d", len(values), dv.Len())
+		}
+		a := reflect.New(dv.Type()).Elem()
+		for i, v := range values {
+			if err := convertAssignValue(a.Index(i).Addr().Interface(), v, &elemFC); err != nil {
+				return fmt.Errorf("list element %d: %w", i, err)
+			}
+		}
+		dv.Set(a)
+	case reflect.Map:
+		if dv.IsNil() {
+			dv.Set(reflect.MakeMapWithSize(dv.Type(), len(values)))
+		}
+		for _, v := range values {
+			i := strings.IndexRune(v, kvSep)
+			if i < 0 {
+				return fmt.Errorf("missing key value separator %q in %q", kvSep, v)
+			}
+			key := reflect.New(dv.Type().Key())
+			if err := convertAssignValue(key.Interface(), strings.TrimSpace(v[:i]), &keyFC); err != nil {
+				return fmt.Errorf("map key %q: %w", v[:i], err)
+			}
+			elem := reflect.New(dv.Type().Elem())
+			if err := convertAssignValue(elem.Interface(), strings.TrimSpace(v[i+utf8.RuneLen(kvSep):]), &elemFC); err != nil {
+				return fmt.Errorf("map value for key %q: %w", v[:i], err)
+			}
+			dv.SetMapIndex(key.Elem(), elem.Elem())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+)
+
+// convertAssignNetwork copies to dest the network address or identifier in src.
+// The boolean is false if dest is not one of the supported types.
+func convertAssignNetwork(dest interface{}, src string) (bool, error) {
+	switch d := dest.(type) {
+	case *net.IP:
+		ip := net.ParseIP(src)
+		if ip == nil {
+			return true, fmt.Errorf("invalid IP address %q", src)
+		}
+		*d = ip
+	case *net.IPNet:
+		_, n, err := net.ParseCIDR(src)
+		if err != nil {
+			return true, err
+		}
+		*d = *n
+	case *net.HardwareAddr:
+		mac, err := net.ParseMAC(src)
+		if err != nil {
+			return true, err
+		}
+		*d = mac
+	case *netip.Addr:
+		addr, err := netip.ParseAddr(src)
+		if err != nil {
+			return true, err
+		}
+		*d = addr
+	case *netip.AddrPort:
+		addrPort, err := netip.ParseAddrPort(src)
+		if err != nil {
+			return true, err
+		}
+		*d = addrPort
+	case *netip.Prefix:
+		prefix, err := netip.ParsePrefix(src)
+		if err != nil {
+			return true, err
+		}
+		*d = prefix
+	case *url.URL:
+		u, err := url.Parse(src)
+		if err != nil {
+			return true, err
+		}
+		// url.Parse accepts any relative reference, e.g. a sentence
+		if u.Scheme == "" {
+			return true, fmt.Errorf("invalid URL %q: missing scheme", src)
+		}
+		if u.Host == "" && (u.Scheme == "http" || u.Scheme == "https") {
+			return true, fmt.Errorf("invalid URL %q: missing host", src)
+		}
+		*d = *u
+	case *mail.Address:
+		addr, err := mail.ParseAddress(src)
+		if err != nil {
+			return true, fmt.Errorf("invalid email address %q: %w", src, err)
+		}
+		*d = *addr
+	default:
+		return false, nil
+	}
+	return true, nil
+}
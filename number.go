@@ -0,0 +1,201 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NumberFormat describes how the numbers are written in the CSV fields,
+// e.g. with a decimal comma or grouping separators.
+// It is used when decoding into the int, uint and float types.
+type NumberFormat struct {
+	DecimalSeparator rune     // the character separating the decimals. Default value is '.'.
+	GroupSeparator   rune     // the character grouping the digits by three, e.g. thousands. It must differ from the decimal separator. A space also matches the no-break spaces.
+	StripCurrency    bool     // if set to true, a leading or trailing currency symbol, e.g. € or $, is removed
+	CurrencyCodes    []string // the currency codes removed before parsing when leading or trailing, e.g. EUR
+	ParenNegatives   bool     // if set to true, a number in parentheses is negative, e.g. (1,234.00)
+	Percent          bool     // if set to true, a number followed by a percent sign is divided by 100
+}
+
+// predefinedNumberFormats are the number formats available by name in the numfmt tag option.
+var predefinedNumberFormats = map[string]NumberFormat{
+	"en": {DecimalSeparator: '.', GroupSeparator: ','},
+	"de": {DecimalSeparator: ',', GroupSeparator: '.'},
+	"fr": {DecimalSeparator: ',', GroupSeparator: ' '},
+	"ch": {DecimalSeparator: '.', GroupSeparator: '\''},
+}
+
+// RegisterNumberFormat makes a number format available under the given name.
+// A struct field tagged with `csv:"name,numfmt=<name>"` is parsed with it.
+// The formats "en", "de", "fr" and "ch" are predefined with the usual
+// decimal and grouping separators of those locales.
+func (c *Config) RegisterNumberFormat(name string, format NumberFormat) {
+	if c.numberFormats == nil {
+		c.numberFormats = make(map[string]NumberFormat)
+	}
+	c.numberFormats[name] = format
+}
+
+// numberFormat returns the number format of the field, or nil if the numbers
+// must be parsed as is. The percent tag option enables the percent scaling.
+func numberFormat(fc *FieldContext) (*NumberFormat, error) {
+	format := fc.Config.NumberFormat
+	if name, ok := fc.Options.Get("numfmt"); ok {
+		f, ok := fc.Config.numberFormats[name]
+		if !ok {
+			if f, ok = predefinedNumberFormats[name]; !ok {
+				return nil, fmt.Errorf("%w: no number format registered as %q", ErrInvalidTag, name)
+			}
+		}
+		if err := f.checkSeparators(); err != nil {
+			return nil, fmt.Errorf("%w: number format %q: %v", ErrInvalidTag, name, err)
+		}
+		format = &f
+	}
+	if fc.Options.Has("percent") {
+		f := NumberFormat{}
+		if format != nil {
+			f = *format
+		}
+		f.Percent = true
+		format = &f
+	}
+	return format, nil
+}
+
+// normalizeNumber rewrites the number in src in the syntax of the strconv package.
+// The boolean is true if the number is a percentage that must be divided by 100.
+func normalizeNumber(src string, format *NumberFormat) (string, bool, error) {
+	if format == nil {
+		return src, false, nil
+	}
+
+	s := strings.TrimSpace(src)
+	negative := false
+	if format.ParenNegatives && strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], strings.TrimSpace(s[1:])
+	}
+	s = format.stripCurrency(s)
+	if sign == "" && (strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+")) {
+		// the sign can follow the currency symbol, e.g. € -4,50
+		sign, s = s[:1], strings.TrimSpace(s[1:])
+	}
+	if sign != "" && (strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+")) {
+		return "", false, fmt.Errorf("invalid number %q: more than one sign", src)
+	}
+	if negative {
+		if sign != "" {
+			return "", false, fmt.Errorf("invalid number %q: sign in parentheses", src)
+		}
+		sign = "-"
+	}
+
+	percent := false
+	if format.Percent && strings.HasSuffix(s, "%") {
+		percent = true
+		s = strings.TrimSpace(strings.TrimSuffix(s, "%"))
+	}
+
+	if format.GroupSeparator != 0 {
+		if i := strings.LastIndex(s, string(format.decimalSeparator())); i >= 0 &&
+			strings.ContainsRune(s[i:], format.GroupSeparator) {
+			return "", false, fmt.Errorf("invalid number %q: group separator after the decimal separator", src)
+		}
+		if !format.validGroups(s) {
+			return "", false, fmt.Errorf("invalid number %q: the digits must be grouped by three", src)
+		}
+		s = strings.Map(func(r rune) rune {
+			if format.isGroupSeparator(r) {
+				return -1
+			}
+			return r
+		}, s)
+	}
+	if sep := format.decimalSeparator(); sep != '.' {
+		if strings.ContainsRune(s, '.') {
+			return "", false, fmt.Errorf("invalid number %q: unexpected '.' with decimal separator %q", src, sep)
+		}
+		s = strings.Replace(s, string(sep), ".", 1)
+	}
+
+	if sign == "+" {
+		// the strconv package doesn't accept a leading plus for unsigned integers
+		sign = ""
+	}
+	return sign + s, percent, nil
+}
+
+// decimalSeparator returns the decimal separator of the format.
+func (f *NumberFormat) decimalSeparator() rune {
+	if f.DecimalSeparator == 0 {
+		return '.'
+	}
+	return f.DecimalSeparator
+}
+
+// checkSeparators returns an error if the decimal separator and the group
+// separator of the format are the same character.
+func (f *NumberFormat) checkSeparators() error {
+	if f.GroupSeparator == f.decimalSeparator() {
+		return fmt.Errorf("the decimal separator and the group separator are both %q", f.GroupSeparator)
+	}
+	return nil
+}
+
+// isGroupSeparator reports whether r separates groups of digits in the format.
+func (f *NumberFormat) isGroupSeparator(r rune) bool {
+	return r == f.GroupSeparator || (f.GroupSeparator == ' ' && (r == '\u00a0' || r == '\u202f'))
+}
+
+// validGroups reports whether the group separators of the integer part of s,
+// ending with the decimal separator or the first character that is neither a
+// digit nor a group separator, are followed by exactly three digits.
+func (f *NumberFormat) validGroups(s string) bool {
+	grouped := false
+	n := 0 // the number of digits of the current group
+loop:
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			n++
+		case f.isGroupSeparator(r):
+			if n == 0 || (grouped && n != 3) {
+				return false
+			}
+			grouped, n = true, 0
+		default:
+			break loop
+		}
+	}
+	return !grouped || n == 3
+}
+
+// stripCurrency removes a leading or trailing currency symbol or code from s.
+func (f *NumberFormat) stripCurrency(s string) string {
+	for _, code := range f.CurrencyCodes {
+		if strings.HasPrefix(s, code) {
+			return strings.TrimSpace(strings.TrimPrefix(s, code))
+		}
+		if strings.HasSuffix(s, code) {
+			return strings.TrimSpace(strings.TrimSuffix(s, code))
+		}
+	}
+	if !f.StripCurrency {
+		return s
+	}
+	if r, size := utf8.DecodeRuneInString(s); unicode.Is(unicode.Sc, r) {
+		return strings.TrimSpace(s[size:])
+	}
+	if r, size := utf8.DecodeLastRuneInString(s); unicode.Is(unicode.Sc, r) {
+		return strings.TrimSpace(s[:len(s)-size])
+	}
+	return s
+}
@@ -0,0 +1,454 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/bits"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// defaultDistinctLimit is the number of distinct values counted exactly per column if none is configured.
+const defaultDistinctLimit = 10000
+
+// defaultTopValues is the number of most frequent values reported per column if none is configured.
+const defaultTopValues = 10
+
+// ProfileOptions configures the profile computed by Decoder.Profile.
+type ProfileOptions struct {
+	MissingValues []string // the values standing for missing values. Default value is the empty string.
+	DistinctLimit int      // the number of distinct values counted exactly per column. Above, the distinct values and the top values are estimated. Default value is 10000.
+	TopValues     int      // the number of most frequent values reported per column. Default value is 10. A negative value disables the top values.
+}
+
+// Profile describes the values of the columns of a file, computed by Decoder.Profile.
+type Profile struct {
+	Rows    int             `json:"rows"`
+	Columns []ColumnProfile `json:"columns"`
+}
+
+// ColumnProfile describes the values of a column.
+type ColumnProfile struct {
+	Name         string                `json:"name"`
+	Type         FieldType             `json:"type"`                // the first of integer, number, boolean, date, datetime and time all the values conform to, or string
+	Count        int                   `json:"count"`               // the number of values, missing values included
+	Missing      int                   `json:"missing"`             // the number of missing values, including the values of the rows too short to have the column
+	MissingRatio float64               `json:"missingRatio"`        // the ratio of the missing values
+	Distinct     int                   `json:"distinct"`            // the number of distinct values, missing values excluded
+	Approximate  bool                  `json:"approximate"`         // the distinct count and the counts of the top values are estimated
+	Min          string                `json:"min,omitempty"`       // the minimum value in the order of the type, as written in the file
+	Max          string                `json:"max,omitempty"`       // the maximum value in the order of the type, as written in the file
+	MinLength    int                   `json:"minLength"`           // the minimum length of the values in characters
+	MaxLength    int                   `json:"maxLength"`           // the maximum length of the values in characters
+	Lengths      []LengthBucket        `json:"lengths,omitempty"`   // the histogram of the lengths of the values, by powers of 2
+	TopValues    []ValueCount          `json:"topValues,omitempty"` // the most frequent values, in decreasing order of count
+	Conformance  map[FieldType]float64 `json:"conformance"`         // the ratio of the values conforming to each type, missing values excluded
+}
+
+// LengthBucket counts the values whose length in characters is between Min and Max included.
+type LengthBucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// ValueCount is a value and its number of occurrences. If the counts are estimated,
+// Count is an upper bound exceeding the real count by at most Error.
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+	Error int    `json:"error,omitempty"`
+}
+
+// profileTypes are the types whose conformance is measured, in the order they are preferred.
+var profileTypes = []FieldType{TypeInteger, TypeNumber, TypeBoolean, TypeDate, TypeDatetime, TypeTime}
+
+// typeProfiler measures the conformance of the values of a column to a type.
+type typeProfiler struct {
+	typ        FieldType
+	parsers    []parseFunc
+	conforming int
+	min, max   string
+	minValue   reflect.Value
+	maxValue   reflect.Value
+}
+
+// topCounter is a counter of the space-saving algorithm, see Metwally et al., Efficient
+// computation of frequent and top-k elements in data streams.
+type topCounter struct {
+	count, err int
+}
+
+// columnProfiler computes the profile of a column in bounded memory.
+type columnProfiler struct {
+	name    string
+	count   int
+	missing int
+
+	// counts holds the exact counts of the values until the distinct limit is exceeded.
+	// Then the distinct values are estimated by hll and the top values by the space-saving
+	// counters in top.
+	counts map[string]int
+	hll    *hyperLogLog
+	top    map[string]*topCounter
+
+	minLength, maxLength int
+	lengths              [bits.UintSize + 1]int
+	minString, maxString string
+	types                []typeProfiler
+}
+
+// profiler computes the profile of the rows of a file.
+type profiler struct {
+	opts    ProfileOptions
+	config  Config
+	missing map[string]bool
+	rows    int
+	columns []*columnProfiler
+}
+
+// Profile reads the remaining rows of the decoder and returns the profile of their columns:
+// the counts of values, missing values and distinct values, the minimum and maximum values,
+// the histogram of the lengths, the most frequent values and the ratio of the values
+// conforming to each type. The values are parsed like by Infer, with the number format
+// of the configuration.
+//
+// The memory used per column is bounded: above DistinctLimit distinct values, the number of
+// distinct values is estimated with a HyperLogLog sketch and the most frequent values with
+// the space-saving algorithm, and the column profile is marked as approximate.
+func (p *Decoder) Profile(opts ProfileOptions) (*Profile, error) {
+	if opts.DistinctLimit <= 0 {
+		opts.DistinctLimit = defaultDistinctLimit
+	}
+	if opts.TopValues == 0 {
+		opts.TopValues = defaultTopValues
+	}
+	pr := &profiler{opts: opts, config: p.config, missing: map[string]bool{"": true}}
+	if opts.MissingValues != nil {
+		pr.missing = make(map[string]bool, len(opts.MissingValues))
+		for _, v := range opts.MissingValues {
+			pr.missing[v] = true
+		}
+	}
+	for i, name := range p.header {
+		if err := pr.addColumn(name, i); err != nil {
+			return nil, err
+		}
+	}
+
+	for p.Next() {
+		if err := pr.add(p.row()); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+	return pr.profile(), nil
+}
+
+// addColumn adds the profiler of a column. The values of the previous rows are missing.
+func (pr *profiler) addColumn(name string, i int) error {
+	if name == "" {
+		name = "field" + strconv.Itoa(i+1)
+	}
+	c := &columnProfiler{
+		name:    name,
+		count:   pr.rows,
+		missing: pr.rows,
+		counts:  make(map[string]int),
+	}
+	for _, typ := range profileTypes {
+		tp := typeProfiler{typ: typ}
+		for _, candidate := range inferCandidates {
+			if candidate.Type != typ {
+				continue
+			}
+			parse, err := candidateParser(candidate, pr.config)
+			if err != nil {
+				return err
+			}
+			tp.parsers = append(tp.parsers, parse)
+		}
+		c.types = append(c.types, tp)
+	}
+	pr.columns = append(pr.columns, c)
+	return nil
+}
+
+// add adds the values of row to the profile.
+func (pr *profiler) add(row Row) error {
+	for i := len(pr.columns); i < row.Len(); i++ {
+		if err := pr.addColumn("", i); err != nil {
+			return err
+		}
+	}
+	pr.rows++
+	for i, c := range pr.columns {
+		c.count++
+		if i >= row.Len() || pr.missing[row.Index(i)] {
+			c.missing++
+			continue
+		}
+		c.add(row.Index(i), &pr.opts)
+	}
+	return nil
+}
+
+// add adds the value s to the profile of the column.
+func (c *columnProfiler) add(s string, opts *ProfileOptions) {
+	present := c.count - c.missing
+
+	l := utf8.RuneCountInString(s)
+	if present == 1 || l < c.minLength {
+		c.minLength = l
+	}
+	if l > c.maxLength {
+		c.maxLength = l
+	}
+	c.lengths[bits.Len(uint(l))]++
+	if present == 1 || s < c.minString {
+		c.minString = s
+	}
+	if present == 1 || s > c.maxString {
+		c.maxString = s
+	}
+
+	c.countValue(s, opts)
+
+	for i := range c.types {
+		tp := &c.types[i]
+		if (tp.typ == TypeInteger || tp.typ == TypeNumber) && hasLeadingZero(s) {
+			continue
+		}
+		for _, parse := range tp.parsers {
+			v, err := parse(s)
+			if err != nil {
+				continue
+			}
+			tp.conforming++
+			tp.update(s, reflect.ValueOf(v))
+			break
+		}
+	}
+}
+
+// countValue counts the occurrences of s, exactly until the distinct limit is exceeded.
+func (c *columnProfiler) countValue(s string, opts *ProfileOptions) {
+	if c.counts != nil {
+		c.counts[s]++
+		if len(c.counts) <= opts.DistinctLimit {
+			return
+		}
+		// switch to the estimates, keeping the most frequent values seen so far
+		c.hll = newHyperLogLog()
+		c.top = make(map[string]*topCounter)
+		for _, vc := range sortedCounts(c.counts, topCapacity(opts)) {
+			c.top[vc.Value] = &topCounter{count: vc.Count}
+		}
+		for v := range c.counts {
+			c.hll.add(v)
+		}
+		c.counts = nil
+		return
+	}
+
+	c.hll.add(s)
+	if opts.TopValues < 0 {
+		return
+	}
+	if t, ok := c.top[s]; ok {
+		t.count++
+		return
+	}
+	if len(c.top) < topCapacity(opts) {
+		c.top[s] = &topCounter{count: 1}
+		return
+	}
+	// the new value replaces the least frequent value, inheriting its count as error
+	var minValue string
+	var minCounter *topCounter
+	for v, t := range c.top {
+		if minCounter == nil || t.count < minCounter.count || t.count == minCounter.count && v < minValue {
+			minValue, minCounter = v, t
+		}
+	}
+	delete(c.top, minValue)
+	c.top[s] = &topCounter{count: minCounter.count + 1, err: minCounter.count}
+}
+
+// topCapacity returns the number of space-saving counters kept per column.
+// More counters than reported values make the reported counts more accurate.
+func topCapacity(opts *ProfileOptions) int {
+	n := 10 * opts.TopValues
+	if n < 100 {
+		n = 100
+	}
+	if n > opts.DistinctLimit {
+		n = opts.DistinctLimit
+	}
+	return n
+}
+
+// update updates the minimum and maximum values of the type with the value v parsed from s.
+func (tp *typeProfiler) update(s string, v reflect.Value) {
+	if !isOrdered(v.Type()) {
+		return
+	}
+	if !tp.minValue.IsValid() {
+		tp.min, tp.minValue, tp.max, tp.maxValue = s, v, s, v
+		return
+	}
+	if c, ok := compare(v, tp.minValue); ok && c < 0 {
+		tp.min, tp.minValue = s, v
+	}
+	if c, ok := compare(v, tp.maxValue); ok && c > 0 {
+		tp.max, tp.maxValue = s, v
+	}
+}
+
+// sortedCounts returns at most n values of counts in decreasing order of count,
+// the values with the same count in increasing order.
+func sortedCounts(counts map[string]int, n int) []ValueCount {
+	values := make([]ValueCount, 0, len(counts))
+	for v, count := range counts {
+		values = append(values, ValueCount{Value: v, Count: count})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].Count != values[j].Count {
+			return values[i].Count > values[j].Count
+		}
+		return values[i].Value < values[j].Value
+	})
+	if len(values) > n {
+		values = values[:n]
+	}
+	return values
+}
+
+// profile returns the profile of the rows added.
+func (pr *profiler) profile() *Profile {
+	p := &Profile{Rows: pr.rows, Columns: make([]ColumnProfile, 0, len(pr.columns))}
+	for _, c := range pr.columns {
+		p.Columns = append(p.Columns, c.profile(&pr.opts))
+	}
+	return p
+}
+
+// profile returns the profile of the column.
+func (c *columnProfiler) profile(opts *ProfileOptions) ColumnProfile {
+	present := c.count - c.missing
+	cp := ColumnProfile{
+		Name:        c.name,
+		Type:        TypeString,
+		Count:       c.count,
+		Missing:     c.missing,
+		MinLength:   c.minLength,
+		MaxLength:   c.maxLength,
+		Min:         c.minString,
+		Max:         c.maxString,
+		Conformance: make(map[FieldType]float64, len(c.types)),
+	}
+	if c.count > 0 {
+		cp.MissingRatio = float64(c.missing) / float64(c.count)
+	}
+
+	for _, tp := range c.types {
+		if present == 0 {
+			cp.Conformance[tp.typ] = 0
+			continue
+		}
+		cp.Conformance[tp.typ] = float64(tp.conforming) / float64(present)
+		if cp.Type == TypeString && tp.conforming == present {
+			cp.Type = tp.typ
+			cp.Min, cp.Max = tp.min, tp.max
+		}
+	}
+
+	for i, n := range c.lengths {
+		if n == 0 {
+			continue
+		}
+		b := LengthBucket{Count: n}
+		if i > 0 {
+			b.Min, b.Max = 1<<(i-1), 1<<i-1
+		}
+		cp.Lengths = append(cp.Lengths, b)
+	}
+
+	if c.counts != nil {
+		cp.Distinct = len(c.counts)
+		if opts.TopValues > 0 {
+			cp.TopValues = sortedCounts(c.counts, opts.TopValues)
+		}
+		return cp
+	}
+
+	cp.Approximate = true
+	cp.Distinct = c.hll.count()
+	if opts.TopValues > 0 {
+		counts := make(map[string]int, len(c.top))
+		for v, t := range c.top {
+			counts[v] = t.count
+		}
+		cp.TopValues = sortedCounts(counts, opts.TopValues)
+		for i := range cp.TopValues {
+			cp.TopValues[i].Error = c.top[cp.TopValues[i].Value].err
+		}
+	}
+	return cp
+}
+
+// Write writes the profile in indented JSON.
+func (p *Profile) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// maxTextValue is the maximum length in characters of the values written by WriteText.
+const maxTextValue = 20
+
+// WriteText writes the profile as a text table with a line per column. The estimated
+// distinct counts are prefixed with ~ and the top values are limited to the three most
+// frequent. The histograms of the lengths and the conformance to the types are only
+// written by Write.
+func (p *Profile) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%d rows\n", p.Rows)
+	fmt.Fprintln(tw, "COLUMN\tTYPE\tMISSING\tDISTINCT\tMIN\tMAX\tLENGTH\tTOP VALUES")
+	for _, c := range p.Columns {
+		distinct := strconv.Itoa(c.Distinct)
+		if c.Approximate {
+			distinct = "~" + distinct
+		}
+		var top []string
+		for i, vc := range c.TopValues {
+			if i == 3 {
+				break
+			}
+			top = append(top, fmt.Sprintf("%s (%d)", textValue(vc.Value), vc.Count))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d (%.1f%%)\t%s\t%s\t%s\t%d-%d\t%s\n",
+			textValue(c.Name), c.Type, c.Missing, 100*c.MissingRatio, distinct,
+			textValue(c.Min), textValue(c.Max), c.MinLength, c.MaxLength, strings.Join(top, ", "))
+	}
+	return tw.Flush()
+}
+
+// textValue returns the value v quoted if it contains spaces or control characters,
+// and shortened to maxTextValue characters.
+func textValue(v string) string {
+	if utf8.RuneCountInString(v) > maxTextValue {
+		v = string([]rune(v)[:maxTextValue-1]) + "…"
+	}
+	if strings.ContainsAny(v, " \t\r\n") || strconv.Quote(v) != `"`+v+`"` {
+		return strconv.Quote(v)
+	}
+	return v
+}
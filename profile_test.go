@@ -0,0 +1,183 @@
+package csvdecoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	data := "id,zip,status,joined,note\n" +
+		"1,01234,open,2020-01-31,hello world\n" +
+		"2,02345,closed,2020-02-01,\n" +
+		"3,03456,open,2021-01-01,NA\n" +
+		"4,04567,open\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	p, err := d.Profile(ProfileOptions{MissingValues: []string{"", "NA"}, TopValues: 2})
+	if err != nil {
+		t.Fatalf("could not profile: %s", err)
+	}
+	if p.Rows != 4 || len(p.Columns) != 5 {
+		t.Fatalf("unexpected profile %+v", p)
+	}
+
+	id := p.Columns[0]
+	if id.Name != "id" || id.Type != TypeInteger || id.Min != "1" || id.Max != "4" || id.Distinct != 4 ||
+		id.Missing != 0 || id.Conformance[TypeInteger] != 1 || id.Conformance[TypeBoolean] != 0.25 || id.Approximate {
+		t.Errorf("unexpected profile of id %+v", id)
+	}
+
+	zip := p.Columns[1]
+	if zip.Type != TypeString || zip.Conformance[TypeInteger] != 0 || zip.Min != "01234" || zip.Max != "04567" {
+		t.Errorf("unexpected profile of zip %+v", zip)
+	}
+
+	status := p.Columns[2]
+	expectedTop := []ValueCount{{Value: "open", Count: 3}, {Value: "closed", Count: 1}}
+	if status.Distinct != 2 || !reflect.DeepEqual(status.TopValues, expectedTop) ||
+		!reflect.DeepEqual(status.Lengths, []LengthBucket{{Min: 4, Max: 7, Count: 4}}) {
+		t.Errorf("unexpected profile of status %+v", status)
+	}
+
+	joined := p.Columns[3]
+	if joined.Type != TypeDate || joined.Missing != 1 || joined.MissingRatio != 0.25 || joined.Min != "2020-01-31" || joined.Max != "2021-01-01" {
+		t.Errorf("unexpected profile of joined %+v", joined)
+	}
+
+	note := p.Columns[4]
+	if note.Missing != 3 || note.Distinct != 1 || note.MinLength != 11 || note.MaxLength != 11 ||
+		!reflect.DeepEqual(note.Lengths, []LengthBucket{{Min: 8, Max: 15, Count: 1}}) {
+		t.Errorf("unexpected profile of note %+v", note)
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestProfileStreams(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,name\n")
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&b, "%d,name %d\n", i, i)
+	}
+	in := &countingReader{r: strings.NewReader(b.String())}
+
+	d, err := NewWithConfig(in, Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	if in.n >= b.Len() {
+		t.Fatalf("expected the file to be read as it is profiled, %d bytes were read before the first row", in.n)
+	}
+	p, err := d.Profile(ProfileOptions{})
+	if err != nil {
+		t.Fatalf("could not profile: %s", err)
+	}
+	if p.Rows != 10000 || p.Columns[0].Distinct < 9900 {
+		t.Errorf("unexpected profile %+v", p)
+	}
+}
+
+func TestProfileApproximate(t *testing.T) {
+	const rows = 20000
+	var b strings.Builder
+	b.WriteString("id,category\n")
+	for i := 0; i < rows; i++ {
+		// the category 0 is the most frequent, then 1, then 2
+		category := i
+		switch {
+		case i%10 < 3:
+			category = 0
+		case i%10 < 5:
+			category = 1
+		case i%10 < 6:
+			category = 2
+		}
+		fmt.Fprintf(&b, "%d,c%d\n", i, category)
+	}
+
+	d, err := NewWithConfig(strings.NewReader(b.String()), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	p, err := d.Profile(ProfileOptions{DistinctLimit: 1000, TopValues: 3})
+	if err != nil {
+		t.Fatalf("could not profile: %s", err)
+	}
+
+	id := p.Columns[0]
+	if !id.Approximate || math.Abs(float64(id.Distinct-rows))/rows > 0.03 {
+		t.Errorf("expected about %d distinct ids, got %d", rows, id.Distinct)
+	}
+	if id.Min != "0" || id.Max != "19999" {
+		t.Errorf("expected the exact bounds, got %s and %s", id.Min, id.Max)
+	}
+
+	category := p.Columns[1]
+	if !category.Approximate || len(category.TopValues) != 3 {
+		t.Fatalf("unexpected profile of category %+v", category)
+	}
+	for i, expected := range []ValueCount{{Value: "c0", Count: 6000}, {Value: "c1", Count: 4000}, {Value: "c2", Count: 2000}} {
+		got := category.TopValues[i]
+		if got.Value != expected.Value || got.Count < expected.Count || got.Count-got.Error > expected.Count {
+			t.Errorf("expected %+v at rank %d, got %+v", expected, i, got)
+		}
+	}
+}
+
+func TestProfileWrite(t *testing.T) {
+	data := "name,score\nAda Lovelace,1\nalan,x\n,3\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	p, err := d.Profile(ProfileOptions{})
+	if err != nil {
+		t.Fatalf("could not profile: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.Write(&buf); err != nil {
+		t.Fatalf("could not write the profile: %s", err)
+	}
+	var read Profile
+	if err := json.Unmarshal(buf.Bytes(), &read); err != nil {
+		t.Fatalf("could not read the profile: %s", err)
+	}
+	if !reflect.DeepEqual(&read, p) {
+		t.Errorf("expected %+v, got %+v", p, read)
+	}
+
+	buf.Reset()
+	if err := p.WriteText(&buf); err != nil {
+		t.Fatalf("could not write the profile: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != "3 rows" || !strings.HasPrefix(lines[1], "COLUMN") {
+		t.Fatalf("unexpected text\n%s", buf.String())
+	}
+	if fields := strings.Fields(lines[2]); fields[0] != "name" || fields[1] != "string" || fields[2] != "1" || fields[3] != "(33.3%)" ||
+		!strings.Contains(lines[2], `"Ada Lovelace" (1)`) {
+		t.Errorf("unexpected line %q", lines[2])
+	}
+	if fields := strings.Fields(lines[3]); fields[0] != "score" || fields[1] != "string" || fields[5] != "1" || fields[6] != "x" {
+		t.Errorf("unexpected line %q", lines[3])
+	}
+}
@@ -0,0 +1,176 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// quantityExpression matches a number followed by an optional unit, e.g. 1.5GiB or 200 MB.
+var quantityExpression = regexp.MustCompile(`^([+-]?(?:[0-9]+\.?[0-9]*|\.[0-9]+)(?:[eE][+-]?[0-9]+)?)\s*(\S*)$`)
+
+// byteUnits are the units of the byte sizes, in lower case.
+var byteUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"pb":  1e15,
+	"eb":  1e18,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+	"pib": 1 << 50,
+	"eib": 1 << 60,
+}
+
+// siPrefixes are the SI prefixes, as numerator and denominator of their factor.
+var siPrefixes = map[string][2]int64{
+	"":  {1, 1},
+	"k": {1e3, 1},
+	"K": {1e3, 1},
+	"M": {1e6, 1},
+	"G": {1e9, 1},
+	"T": {1e12, 1},
+	"P": {1e15, 1},
+	"E": {1e18, 1},
+	"m": {1, 1e3},
+	"u": {1, 1e6},
+	"µ": {1, 1e6},
+	"μ": {1, 1e6},
+	"n": {1, 1e9},
+}
+
+// convertAssignQuantity copies to dv, an integer or a float, the quantity in src
+// expressed in the given unit system: bytes for byte sizes like 1.5GiB or si for
+// SI prefixes like 3k. A quantity stored into an integer must be a whole number.
+func convertAssignQuantity(dv reflect.Value, src string, unit string) error {
+	if unit != "bytes" && unit != "si" {
+		return fmt.Errorf("%w: unknown unit %q, expected bytes or si", ErrInvalidTag, unit)
+	}
+
+	match := quantityExpression.FindStringSubmatch(strings.TrimSpace(src))
+	if match == nil {
+		return fmt.Errorf("invalid quantity %q", src)
+	}
+	value, ok := new(big.Rat).SetString(match[1])
+	if !ok {
+		return fmt.Errorf("invalid quantity %q", src)
+	}
+
+	var factor *big.Rat
+	if unit == "bytes" {
+		size, ok := byteUnits[strings.ToLower(match[2])]
+		if !ok {
+			return fmt.Errorf("invalid quantity %q: unknown byte unit %q", src, match[2])
+		}
+		factor = new(big.Rat).SetInt64(size)
+	} else {
+		prefix, ok := siPrefixes[match[2]]
+		if !ok {
+			return fmt.Errorf("invalid quantity %q: unknown SI prefix %q", src, match[2])
+		}
+		factor = big.NewRat(prefix[0], prefix[1])
+	}
+	value.Mul(value, factor)
+
+	switch dv.Kind() {
+	case reflect.Float32, reflect.Float64:
+		f, _ := value.Float64()
+		dv.SetFloat(f)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !value.IsInt() {
+			return fmt.Errorf("quantity %q is not a whole number", src)
+		}
+		i64, err := parseInt(value.Num().String(), dv.Type(), IntFormat{})
+		if err != nil {
+			return err
+		}
+		dv.SetInt(i64)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !value.IsInt() {
+			return fmt.Errorf("quantity %q is not a whole number", src)
+		}
+		u64, err := parseUint(value.Num().String(), dv.Type(), IntFormat{})
+		if err != nil {
+			return err
+		}
+		dv.SetUint(u64)
+		return nil
+	}
+	return fmt.Errorf("%w: unit requires an integer or a float, got %s", ErrInvalidTag, dv.Type())
+}
+
+// durationExpression matches a component of a duration, e.g. 2h or 1.5d.
+var durationExpression = regexp.MustCompile(`([0-9]*\.?[0-9]+)([a-zµμ]+)`)
+
+// parseDuration returns the duration in s, written like for time.ParseDuration
+// with in addition the d (24h) and w (7d) units, e.g. 1w2d or 2h30m, or in the
+// ISO 8601 syntax without years and months, e.g. P1DT2H.
+func parseDuration(s string) (time.Duration, error) {
+	if strings.HasPrefix(strings.TrimPrefix(s, "-"), "P") {
+		return parseISODuration(s)
+	}
+	body, negative := s, false
+	if strings.HasPrefix(s, "+") || strings.HasPrefix(s, "-") {
+		body, negative = s[1:], s[0] == '-'
+	}
+
+	var days float64
+	var rest strings.Builder
+	pos := 0
+	for _, m := range durationExpression.FindAllStringSubmatchIndex(body, -1) {
+		if m[0] != pos {
+			break
+		}
+		pos = m[1]
+		switch unit := body[m[4]:m[5]]; unit {
+		case "d", "w":
+			var v float64
+			if _, err := fmt.Sscan(body[m[2]:m[3]], &v); err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			if unit == "w" {
+				v *= 7
+			}
+			days += v
+		default:
+			rest.WriteString(body[m[0]:m[1]])
+		}
+	}
+	if pos != len(body) || body == "" {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+
+	dayLength := days * float64(24*time.Hour)
+	if dayLength >= math.MaxInt64 {
+		return 0, fmt.Errorf("invalid duration %q: %w", s, strconv.ErrRange)
+	}
+	d := time.Duration(dayLength)
+	if rest.Len() > 0 {
+		r, err := time.ParseDuration(rest.String())
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		if d > math.MaxInt64-r {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, strconv.ErrRange)
+		}
+		d += r
+	}
+	if negative {
+		d = -d
+	}
+	return d, nil
+}
@@ -0,0 +1,109 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// RegisterType registers a function converting the CSV fields into values of type t.
+// It is used for all the scan targets and struct fields of type t (or pointer to t)
+// and takes precedence over the built-in conversions and the decoder interfaces.
+func (c *Config) RegisterType(t reflect.Type, fn ConvertFunc) {
+	if c.typeConverters == nil {
+		c.typeConverters = make(map[reflect.Type]ConvertFunc)
+	}
+	c.typeConverters[t] = fn
+}
+
+// RegisterColumn registers a function converting the CSV fields of the named column.
+// The column is identified by its name in the header. A function registered for a
+// column takes precedence over a function registered for a type.
+func (c *Config) RegisterColumn(column string, fn ConvertFunc) {
+	if c.columnConverters == nil {
+		c.columnConverters = make(map[string]ConvertFunc)
+	}
+	c.columnConverters[column] = fn
+}
+
+// converter returns the registered function for the field of type t in the given column,
+// or nil if there is none.
+func (c *Config) converter(column string, t reflect.Type) ConvertFunc {
+	if fn, ok := c.columnConverters[column]; ok && column != "" {
+		return fn
+	}
+	for {
+		if fn, ok := c.typeConverters[t]; ok {
+			return fn
+		}
+		if t.Kind() != reflect.Ptr {
+			return nil
+		}
+		t = t.Elem()
+	}
+}
+
+// assignConverted converts src using fn and stores the result in dv,
+// allocating the pointers on the way if needed.
+func assignConverted(dv reflect.Value, fn ConvertFunc, src string) error {
+	v, err := fn(src)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for {
+		switch {
+		case rv.Type().AssignableTo(dv.Type()):
+			dv.Set(rv)
+			return nil
+		case rv.Type().ConvertibleTo(dv.Type()) && kindFamily(rv.Kind()) == kindFamily(dv.Kind()):
+			if overflows(dv, rv) {
+				return fmt.Errorf("converter returned %v which overflows type %s", v, dv.Type())
+			}
+			dv.Set(rv.Convert(dv.Type()))
+			return nil
+		case dv.Kind() == reflect.Ptr:
+			if dv.IsNil() {
+				dv.Set(reflect.New(dv.Type().Elem()))
+			}
+			dv = dv.Elem()
+		default:
+			return fmt.Errorf("converter returned type %T which can't be stored into type %s", v, dv.Type())
+		}
+	}
+}
+
+// kindFamily returns the kind of the values of kind k, the sizes of the numbers aside.
+// The values returned by a converter are only converted within a family, so that an
+// int isn't stored as a rune into a string nor a float truncated into an int.
+func kindFamily(k reflect.Kind) reflect.Kind {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflect.Int
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflect.Uint
+	case reflect.Float32, reflect.Float64:
+		return reflect.Float64
+	case reflect.Complex64, reflect.Complex128:
+		return reflect.Complex128
+	}
+	return k
+}
+
+// overflows reports whether the number rv can't be represented by the type of dv.
+func overflows(dv, rv reflect.Value) bool {
+	switch kindFamily(rv.Kind()) {
+	case reflect.Int:
+		return dv.OverflowInt(rv.Int())
+	case reflect.Uint:
+		return dv.OverflowUint(rv.Uint())
+	case reflect.Float64:
+		return dv.OverflowFloat(rv.Float())
+	case reflect.Complex128:
+		return dv.OverflowComplex(rv.Complex())
+	}
+	return false
+}
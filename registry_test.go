@@ -0,0 +1,194 @@
+package csvdecoder
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type Cents int64
+
+type Temperature struct {
+	Celsius float64
+}
+
+func parseCents(s string) (interface{}, error) {
+	parts := strings.SplitN(s, ".", 2)
+	units, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	var cents int64
+	if len(parts) == 2 {
+		if cents, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return Cents(units*100 + cents), nil
+}
+
+func parseTemperature(s string) (interface{}, error) {
+	f, err := strconv.ParseFloat(strings.TrimSuffix(s, "C"), 64)
+	if err != nil {
+		return nil, err
+	}
+	return Temperature{Celsius: f}, nil
+}
+
+func TestRegisterType(t *testing.T) {
+	type Reading struct {
+		City    string       `csv:"city"`
+		Min     Temperature  `csv:"min"`
+		Max     *Temperature `csv:"max"`
+		Average int64        `csv:"avg"`
+	}
+
+	config := Config{IgnoreHeaders: true}
+	config.RegisterType(reflect.TypeOf(Temperature{}), parseTemperature)
+	config.RegisterColumn("avg", func(s string) (interface{}, error) {
+		return strconv.ParseInt(strings.TrimSuffix(s, "C"), 10, 64)
+	})
+
+	d, err := NewWithConfig(strings.NewReader("city,min,max,avg\nBerlin,-2.5C,10C,4C\n"), config)
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := Reading{
+		City:    "Berlin",
+		Min:     Temperature{Celsius: -2.5},
+		Max:     &Temperature{Celsius: 10},
+		Average: 4,
+	}
+	for d.Next() {
+		var r Reading
+		if err := d.Decode(&r); err != nil {
+			t.Error(err)
+		}
+		if !reflect.DeepEqual(r, expected) {
+			t.Errorf("expected value '%v' got '%v'", expected, r)
+		}
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+}
+
+func TestRegisterColumn(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		data          string
+		expected      int64
+		expectedError bool
+	}{
+		{
+			name:     "should use the column converter",
+			data:     "price\n12.05\n",
+			expected: 1205,
+		},
+		{
+			name:          "should return the converter error",
+			data:          "price\n12.x\n",
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{IgnoreHeaders: true}
+			config.RegisterColumn("price", parseCents)
+			d, err := NewWithConfig(strings.NewReader(tc.data), config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				var price int64
+				err := d.Scan(&price)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				var numErr *strconv.NumError
+				if tc.expectedError && !errors.As(err, &numErr) {
+					t.Errorf("expected the converter error, got '%v'", err)
+				}
+				if price != tc.expected {
+					t.Errorf("expected value '%d' got '%d'", tc.expected, price)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
+
+func TestRegisterColumnConversion(t *testing.T) {
+	type Label string
+
+	for _, tc := range []struct {
+		name          string
+		value         interface{}
+		dest          interface{}
+		expected      interface{}
+		expectedError bool
+	}{
+		{
+			name:     "should convert between integer sizes",
+			value:    42,
+			dest:     new(int8),
+			expected: int8(42),
+		},
+		{
+			name:     "should convert to a named string",
+			value:    "a",
+			dest:     new(Label),
+			expected: Label("a"),
+		},
+		{
+			name:          "should not store an int as a rune",
+			value:         65,
+			dest:          new(string),
+			expected:      "",
+			expectedError: true,
+		},
+		{
+			name:          "should not truncate a float",
+			value:         1.5,
+			dest:          new(int),
+			expected:      0,
+			expectedError: true,
+		},
+		{
+			name:          "should reject an overflow",
+			value:         300,
+			dest:          new(int8),
+			expected:      int8(0),
+			expectedError: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{IgnoreHeaders: true}
+			config.RegisterColumn("a", func(string) (interface{}, error) { return tc.value, nil })
+			d, err := NewWithConfig(strings.NewReader("a\nx\n"), config)
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			for d.Next() {
+				err := d.Scan(tc.dest)
+				if (err != nil) != tc.expectedError {
+					t.Errorf("unexpected error: %v", err)
+				}
+				if got := reflect.ValueOf(tc.dest).Elem().Interface(); got != tc.expected {
+					t.Errorf("expected value '%v' got '%v'", tc.expected, got)
+				}
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+		})
+	}
+}
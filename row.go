@@ -0,0 +1,114 @@
+package csvdecoder
+
+import "fmt"
+
+// Row gives access to the fields of a CSV record,
+// by index or by column name.
+type Row struct {
+	number int
+	header []string
+	values []string
+	config *Config
+}
+
+// Number returns the number of the record in the file, starting at 1.
+// The header line, if any, is counted as a record.
+func (r Row) Number() int {
+	return r.number
+}
+
+// Len returns the number of fields in the row.
+func (r Row) Len() int {
+	return len(r.values)
+}
+
+// Index returns the field at index i.
+// It returns an empty string if the row has no such field.
+func (r Row) Index(i int) string {
+	if i < 0 || i >= len(r.values) {
+		return ""
+	}
+	return r.values[i]
+}
+
+// Get returns the field in the given column.
+// The boolean is false if the header has no such column
+// or the row has no value for it.
+func (r Row) Get(column string) (string, bool) {
+	for i, name := range r.header {
+		if name == column {
+			if i >= len(r.values) {
+				return "", false
+			}
+			return r.values[i], true
+		}
+	}
+	return "", false
+}
+
+// Header returns the names of the columns. It is nil if
+// the decoder was not configured to read the headers.
+func (r Row) Header() []string {
+	return r.header
+}
+
+// Values returns the fields of the row.
+func (r Row) Values() []string {
+	return r.values
+}
+
+// Scan copies the fields of the row into the values pointed at by dest,
+// converting them like Decoder.Scan. The number of dest values must
+// match the number of fields.
+func (r Row) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.values) {
+		return fmt.Errorf("%w: got %d scan targets and %d fields",
+			ErrScanTargetsNotMatch,
+			len(dest),
+			len(r.values),
+		)
+	}
+	for i, val := range r.values {
+		fc := r.fieldContext(i)
+		if err := convertAssignValue(dest[i], val, &fc); err != nil {
+			return &FieldError{
+				Row:    fc.Row,
+				Index:  i,
+				Column: fc.Column,
+				Err:    err,
+				scan:   true,
+			}
+		}
+	}
+	return nil
+}
+
+// subset returns a row containing only the fields at the given indexes.
+func (r Row) subset(indexes []int) Row {
+	s := Row{
+		number: r.number,
+		header: make([]string, len(indexes)),
+		values: make([]string, len(indexes)),
+		config: r.config,
+	}
+	for i, idx := range indexes {
+		s.header[i] = r.header[idx]
+		s.values[i] = r.Index(idx)
+	}
+	return s
+}
+
+// fieldContext returns the context of the field at index i.
+func (r Row) fieldContext(i int) FieldContext {
+	fc := FieldContext{
+		Index: i,
+		Row:   r.number,
+	}
+	if i >= 0 && i < len(r.header) {
+		fc.Column = r.header[i]
+	}
+	if r.config != nil {
+		fc.Config = *r.config
+	}
+	return fc
+}
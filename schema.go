@@ -0,0 +1,117 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// FieldType is the type of a field in a Schema, as named by the Table Schema specification.
+type FieldType string
+
+const (
+	TypeString    FieldType = "string"    // decoded as a string, or a []byte for the binary format
+	TypeInteger   FieldType = "integer"   // decoded as an int64
+	TypeNumber    FieldType = "number"    // decoded as a float64
+	TypeBoolean   FieldType = "boolean"   // decoded as a bool
+	TypeDate      FieldType = "date"      // decoded as a time.Time
+	TypeTime      FieldType = "time"      // decoded as a time.Time
+	TypeDatetime  FieldType = "datetime"  // decoded as a time.Time
+	TypeYear      FieldType = "year"      // decoded as an int64
+	TypeYearMonth FieldType = "yearmonth" // decoded as a time.Time
+	TypeDuration  FieldType = "duration"  // decoded as a time.Duration from an ISO 8601 duration, e.g. P1DT2H
+	TypeObject    FieldType = "object"    // decoded as a map[string]interface{} from a JSON object
+	TypeArray     FieldType = "array"     // decoded as a []interface{} from a JSON array
+	TypeAny       FieldType = "any"       // decoded as a string
+)
+
+// Schema describes the columns of a CSV file without Go struct: their names, types,
+// formats and constraints. It is read from and written to the JSON of the Frictionless
+// Table Schema specification, see https://specs.frictionlessdata.io/table-schema/.
+// The rows are decoded with a schema by Decoder.DecodeSchema.
+type Schema struct {
+	Fields        []SchemaField `json:"fields"`
+	PrimaryKey    FieldNames    `json:"primaryKey,omitempty"`    // the fields whose values identify a row. They are required and unique.
+	MissingValues []string      `json:"missingValues,omitempty"` // the values standing for a missing value. Default value is the empty string.
+}
+
+// SchemaField describes a column of a Schema.
+type SchemaField struct {
+	Name        string       `json:"name"`
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Type        FieldType    `json:"type,omitempty"`   // the type of the values. Default value is string.
+	Format      string       `json:"format,omitempty"` // the format of the values, e.g. email for a string or %d/%m/%Y for a date. Default value is default.
+	Constraints *Constraints `json:"constraints,omitempty"`
+
+	TrueValues  []string `json:"trueValues,omitempty"`  // the tokens of the true booleans. Default value is true, True, TRUE and 1.
+	FalseValues []string `json:"falseValues,omitempty"` // the tokens of the false booleans. Default value is false, False, FALSE and 0.
+	DecimalChar string   `json:"decimalChar,omitempty"` // the decimal separator of the numbers. Default value is '.'.
+	GroupChar   string   `json:"groupChar,omitempty"`   // the character grouping the digits of the numbers and integers, e.g. thousands
+	BareNumber  *bool    `json:"bareNumber,omitempty"`  // if set to false, the characters around the numbers are removed, e.g. currencies. Default value is true.
+
+	MissingValues []string `json:"missingValues,omitempty"` // the values standing for a missing value in this field. Default value is the missing values of the schema.
+
+	Stats *FieldStats `json:"stats,omitempty"` // the statistics of the sampled values if the field was inferred by Infer
+}
+
+// Constraints are the constraints on the values of a SchemaField.
+// The bounds and the allowed values are given in the format of the field.
+type Constraints struct {
+	Required  bool              `json:"required,omitempty"`  // the value must not be missing
+	Unique    bool              `json:"unique,omitempty"`    // the value must be different in every row
+	MinLength *int              `json:"minLength,omitempty"` // the minimum length of a string, an array or an object
+	MaxLength *int              `json:"maxLength,omitempty"` // the maximum length of a string, an array or an object
+	Minimum   json.RawMessage   `json:"minimum,omitempty"`   // the minimum of a number, a date or a duration
+	Maximum   json.RawMessage   `json:"maximum,omitempty"`   // the maximum of a number, a date or a duration
+	Pattern   string            `json:"pattern,omitempty"`   // a regular expression matching the whole value
+	Enum      []json.RawMessage `json:"enum,omitempty"`      // the allowed values
+}
+
+// FieldNames is a list of field names. In JSON, a single name can be written as a string.
+type FieldNames []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (n *FieldNames) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*n = FieldNames{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+// ReadSchema reads a schema written in the JSON of the Table Schema specification.
+// It returns an error wrapping ErrInvalidSchema if the schema can't be used to decode rows.
+func ReadSchema(r io.Reader) (*Schema, error) {
+	var s Schema
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	if _, err := s.compile(nil, Config{}); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Write writes the schema in the JSON of the Table Schema specification.
+func (s *Schema) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// Field returns the field with the given name, or nil if there is none.
+func (s *Schema) Field(name string) *SchemaField {
+	for i := range s.Fields {
+		if s.Fields[i].Name == name {
+			return &s.Fields[i]
+		}
+	}
+	return nil
+}
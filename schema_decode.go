@@ -0,0 +1,337 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// schemaMapping describes how the rows of a file are decoded with a Schema.
+type schemaMapping struct {
+	fields     []schemaFieldMapping
+	missing    map[string]bool
+	primaryKey []int          // the indexes in fields of the primary key fields
+	keys       map[string]int // the rows of the primary keys seen so far
+	positional bool           // the fields are matched with the columns by position and the rows must have a value per field
+}
+
+// schemaFieldMapping describes how a column is decoded into a value of a schema field.
+type schemaFieldMapping struct {
+	name     string
+	missing  map[string]bool // the missing values of the field, nil if they are the missing values of the schema
+	column   int             // the index of the column, -1 if the column is missing
+	parse    parseFunc
+	required bool
+	unique   map[string]int // the rows of the values seen so far, nil if the values are not unique
+
+	minLength, maxLength *int
+	minimum, maximum     interface{}
+	pattern              *regexp.Regexp
+	enum                 map[string]bool
+	enumNames            []string
+}
+
+// compile matches the fields of the schema with the columns in header and prepares
+// the conversion and the constraints of the fields. The fields are matched by name, or
+// by title if no column has their name. If header is nil, the fields are matched with
+// the columns by position.
+func (s *Schema) compile(header []string, config Config) (*schemaMapping, error) {
+	m := &schemaMapping{
+		missing: make(map[string]bool),
+		keys:    make(map[string]int),
+	}
+	if s.MissingValues == nil {
+		m.missing[""] = true
+	}
+	for _, v := range s.MissingValues {
+		m.missing[v] = true
+	}
+
+	names := make(map[string]int, len(s.Fields))
+	bound := make([]bool, len(header))
+	for i := range s.Fields {
+		f := &s.Fields[i]
+		if f.Name == "" {
+			return nil, fmt.Errorf("%w: field %d has no name", ErrInvalidSchema, i)
+		}
+		if _, ok := names[f.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate field %s", ErrInvalidSchema, f.Name)
+		}
+		names[f.Name] = i
+
+		fm, err := f.compile(config)
+		if err != nil {
+			return nil, err
+		}
+		fm.column = i
+		if header != nil {
+			if fm.column, err = columnIndex(header, f.Name); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			if fm.column < 0 && f.Title != "" {
+				if fm.column, err = columnIndex(header, f.Title); err != nil {
+					return nil, fmt.Errorf("field %s: %w", f.Name, err)
+				}
+			}
+			if fm.column < 0 && !config.IgnoreUnmatchingFields {
+				return nil, fmt.Errorf("%w: no column %q for field %s", ErrScanTargetsNotMatch, f.Name, f.Name)
+			}
+			if fm.column >= 0 {
+				bound[fm.column] = true
+			}
+		}
+		m.fields = append(m.fields, fm)
+	}
+	m.positional = header == nil && !config.IgnoreUnmatchingFields
+	if !config.IgnoreUnmatchingFields {
+		for i, ok := range bound {
+			if !ok {
+				return nil, fmt.Errorf("%w: no field for column %q", ErrScanTargetsNotMatch, header[i])
+			}
+		}
+	}
+
+	for _, name := range s.PrimaryKey {
+		i, ok := names[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: primary key field %s is not in the schema", ErrInvalidSchema, name)
+		}
+		m.fields[i].required = true
+		m.primaryKey = append(m.primaryKey, i)
+	}
+	return m, nil
+}
+
+// compile prepares the conversion and the constraints of the field.
+func (f *SchemaField) compile(config Config) (schemaFieldMapping, error) {
+	fm := schemaFieldMapping{name: f.Name}
+	parse, err := f.parser(config)
+	if err != nil {
+		return fm, err
+	}
+	fm.parse = parse
+	if f.MissingValues != nil {
+		fm.missing = make(map[string]bool, len(f.MissingValues))
+		for _, v := range f.MissingValues {
+			fm.missing[v] = true
+		}
+	}
+
+	c := f.Constraints
+	if c == nil {
+		return fm, nil
+	}
+	fm.required = c.Required
+	if c.Unique {
+		fm.unique = make(map[string]int)
+	}
+	fm.minLength, fm.maxLength = c.MinLength, c.MaxLength
+
+	for _, b := range []struct {
+		raw  json.RawMessage
+		dest *interface{}
+		name string
+	}{
+		{c.Minimum, &fm.minimum, "minimum"},
+		{c.Maximum, &fm.maximum, "maximum"},
+	} {
+		if b.raw == nil {
+			continue
+		}
+		v, err := fm.parse(constraintValue(b.raw))
+		if err != nil {
+			return fm, fmt.Errorf("%w: field %s: invalid %s: %v", ErrInvalidSchema, f.Name, b.name, err)
+		}
+		if !isOrdered(reflect.TypeOf(v)) {
+			return fm, fmt.Errorf("%w: field %s: %s requires a number, a date or a duration", ErrInvalidSchema, f.Name, b.name)
+		}
+		*b.dest = v
+	}
+
+	if c.Pattern != "" {
+		if fm.pattern, err = regexp.Compile("^(?:" + c.Pattern + ")$"); err != nil {
+			return fm, fmt.Errorf("%w: field %s: invalid pattern: %v", ErrInvalidSchema, f.Name, err)
+		}
+	}
+
+	if c.Enum != nil {
+		fm.enum = make(map[string]bool, len(c.Enum))
+		for _, raw := range c.Enum {
+			name := constraintValue(raw)
+			v, err := fm.parse(name)
+			if err != nil {
+				return fm, fmt.Errorf("%w: field %s: invalid enum value %s: %v", ErrInvalidSchema, f.Name, raw, err)
+			}
+			fm.enum[valueKey(v)] = true
+			fm.enumNames = append(fm.enumNames, name)
+		}
+	}
+	return fm, nil
+}
+
+// constraintValue returns the value of a constraint as written in a CSV field.
+// The strings are unquoted and the other JSON values are kept as is.
+func constraintValue(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// valueKey returns a string identifying the value v, used to compare the values
+// with the allowed values and with the values of the previous rows.
+func valueKey(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.UTC().Format(time.RFC3339Nano)
+	case []byte:
+		return string(t)
+	case string:
+		return t
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// decode converts the values of row into a map keyed by the name of the schema fields,
+// then checks the constraints of the fields. The missing values are mapped to nil.
+func (m *schemaMapping) decode(row Row) (map[string]interface{}, error) {
+	if m.positional && row.Len() != len(m.fields) {
+		return nil, fmt.Errorf("%w: got %d fields in the schema and %d values", ErrScanTargetsNotMatch, len(m.fields), row.Len())
+	}
+	result := make(map[string]interface{}, len(m.fields))
+	for i := range m.fields {
+		f := &m.fields[i]
+		raw, present := "", f.column >= 0 && f.column < row.Len()
+		if present {
+			raw = row.Index(f.column)
+		}
+		missing := m.missing
+		if f.missing != nil {
+			missing = f.missing
+		}
+		if !present || missing[raw] {
+			if f.required {
+				return nil, f.fieldError(row, fmt.Errorf("%w: a value is required", ErrValidation))
+			}
+			result[f.name] = nil
+			continue
+		}
+
+		v, err := f.parse(raw)
+		if err != nil {
+			return nil, f.fieldError(row, err)
+		}
+		if err := f.check(raw, v); err != nil {
+			return nil, f.fieldError(row, err)
+		}
+		result[f.name] = v
+	}
+
+	// the values are recorded once the whole row is valid
+	key, err := m.checkPrimaryKey(row, result)
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.fields {
+		f := &m.fields[i]
+		if err := f.checkUnique(row, result[f.name]); err != nil {
+			return nil, err
+		}
+	}
+	if key != "" {
+		m.keys[key] = row.Number()
+	}
+	for i := range m.fields {
+		f := &m.fields[i]
+		if f.unique != nil && result[f.name] != nil {
+			f.unique[valueKey(result[f.name])] = row.Number()
+		}
+	}
+	return result, nil
+}
+
+// check checks the constraints of the field on the value v decoded from raw.
+func (f *schemaFieldMapping) check(raw string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if l, ok := length(rv); ok {
+		if f.minLength != nil && l < *f.minLength {
+			return fmt.Errorf("%w: length %d is less than the minimum %d", ErrValidation, l, *f.minLength)
+		}
+		if f.maxLength != nil && l > *f.maxLength {
+			return fmt.Errorf("%w: length %d is greater than the maximum %d", ErrValidation, l, *f.maxLength)
+		}
+	}
+	if f.minimum != nil {
+		if c, ok := compare(rv, reflect.ValueOf(f.minimum)); ok && c < 0 {
+			return fmt.Errorf("%w: %s is less than the minimum", ErrValidation, raw)
+		}
+	}
+	if f.maximum != nil {
+		if c, ok := compare(rv, reflect.ValueOf(f.maximum)); ok && c > 0 {
+			return fmt.Errorf("%w: %s is greater than the maximum", ErrValidation, raw)
+		}
+	}
+	if f.pattern != nil && !f.pattern.MatchString(raw) {
+		return fmt.Errorf("%w: %q does not match the pattern", ErrValidation, raw)
+	}
+	if f.enum != nil && !f.enum[valueKey(v)] {
+		return notOneOfError(raw, f.enumNames)
+	}
+	return nil
+}
+
+// checkUnique checks that the value v of the field was not seen in the previous rows.
+func (f *schemaFieldMapping) checkUnique(row Row, v interface{}) error {
+	if f.unique == nil || v == nil {
+		return nil
+	}
+	if first, ok := f.unique[valueKey(v)]; ok {
+		return f.fieldError(row, fmt.Errorf("%w: duplicate value, first seen on row %d", ErrValidation, first))
+	}
+	return nil
+}
+
+// checkPrimaryKey checks that the primary key of the decoded values was not seen in
+// the previous rows. It returns the key, empty if the schema has no primary key.
+func (m *schemaMapping) checkPrimaryKey(row Row, values map[string]interface{}) (string, error) {
+	if len(m.primaryKey) == 0 {
+		return "", nil
+	}
+	parts := make([]string, len(m.primaryKey))
+	names := make([]string, len(m.primaryKey))
+	for i, idx := range m.primaryKey {
+		names[i] = m.fields[idx].name
+		parts[i] = valueKey(values[names[i]])
+	}
+	key := strings.Join(parts, "\x00")
+	if first, ok := m.keys[key]; ok {
+		err := fmt.Errorf("%w: duplicate primary key (%s), first seen on row %d", ErrValidation, strings.Join(names, ", "), first)
+		if len(m.primaryKey) == 1 {
+			return "", m.fields[m.primaryKey[0]].fieldError(row, err)
+		}
+		return "", &FieldError{Row: row.Number(), Index: -1, Err: err}
+	}
+	return key, nil
+}
+
+// fieldError positions the error err at the column of the field in row.
+func (f *schemaFieldMapping) fieldError(row Row, err error) error {
+	column := f.name
+	if f.column >= 0 && f.column < len(row.header) {
+		column = row.header[f.column]
+	}
+	return &FieldError{
+		Row:    row.Number(),
+		Index:  f.column,
+		Column: column,
+		Err:    err,
+	}
+}
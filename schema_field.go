@@ -0,0 +1,341 @@
+package csvdecoder
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	defaultTrueValues  = []string{"true", "True", "TRUE", "1"}
+	defaultFalseValues = []string{"false", "False", "FALSE", "0"}
+)
+
+// defaultTimeFormats are the Go layouts of the default formats of the date and time types.
+var defaultTimeFormats = map[FieldType]string{
+	TypeDate:      "2006-01-02",
+	TypeTime:      "15:04:05",
+	TypeDatetime:  time.RFC3339,
+	TypeYearMonth: "2006-01",
+}
+
+// parseFunc converts a CSV field into the value of a schema field.
+type parseFunc func(s string) (interface{}, error)
+
+// parser returns the function converting the CSV fields into values of the field f.
+func (f *SchemaField) parser(config Config) (parseFunc, error) {
+	format := f.Format
+	if format == "" {
+		format = "default"
+	}
+	unsupported := fmt.Errorf("%w: field %s: unsupported format %q for type %s", ErrInvalidSchema, f.Name, format, f.typ())
+
+	switch f.typ() {
+	case TypeString:
+		switch format {
+		case "default":
+			return func(s string) (interface{}, error) { return s, nil }, nil
+		case "email":
+			return parseEmail, nil
+		case "uri":
+			return parseURI, nil
+		case "uuid":
+			return func(s string) (interface{}, error) {
+				if _, err := ParseUUID(s); err != nil {
+					return nil, err
+				}
+				return s, nil
+			}, nil
+		case "binary":
+			return func(s string) (interface{}, error) { return base64.StdEncoding.DecodeString(s) }, nil
+		}
+		return nil, unsupported
+
+	case TypeAny:
+		return func(s string) (interface{}, error) { return s, nil }, nil
+
+	case TypeInteger, TypeYear:
+		if format != "default" {
+			return nil, unsupported
+		}
+		nf, err := f.numberFormat()
+		if err != nil {
+			return nil, err
+		}
+		bare := f.BareNumber == nil || *f.BareNumber
+		year := f.typ() == TypeYear
+		return func(s string) (interface{}, error) {
+			if !bare {
+				s = trimNumber(s)
+			}
+			num, _, err := normalizeNumber(s, nf)
+			if err != nil {
+				return nil, err
+			}
+			i, err := strconv.ParseInt(num, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if year && (i < 0 || i > 9999) {
+				return nil, fmt.Errorf("invalid year %d", i)
+			}
+			return i, nil
+		}, nil
+
+	case TypeNumber:
+		if format != "default" {
+			return nil, unsupported
+		}
+		nf, err := f.numberFormat()
+		if err != nil {
+			return nil, err
+		}
+		bare := f.BareNumber == nil || *f.BareNumber
+		return func(s string) (interface{}, error) {
+			if !bare {
+				s = trimNumber(s)
+			}
+			switch s {
+			case "NaN", "INF", "-INF":
+				return strconv.ParseFloat(s, 64)
+			}
+			num, _, err := normalizeNumber(s, nf)
+			if err != nil {
+				return nil, err
+			}
+			return strconv.ParseFloat(num, 64)
+		}, nil
+
+	case TypeBoolean:
+		if format != "default" {
+			return nil, unsupported
+		}
+		bf := &BoolFormat{True: f.TrueValues, False: f.FalseValues, Strict: true}
+		if bf.True == nil {
+			bf.True = defaultTrueValues
+		}
+		if bf.False == nil {
+			bf.False = defaultFalseValues
+		}
+		return func(s string) (interface{}, error) { return parseBool(s, bf) }, nil
+
+	case TypeDate, TypeTime, TypeDatetime, TypeYearMonth:
+		var layouts []string
+		switch {
+		case format == "default":
+			layouts = []string{defaultTimeFormats[f.typ()]}
+		case format == "any":
+			layouts = config.TimeLayouts
+			if layouts == nil {
+				layouts = defaultTimeLayouts
+			}
+			layouts = append(layouts[:len(layouts):len(layouts)], defaultTimeFormats[f.typ()])
+		default:
+			layout, err := strptimeLayout(format)
+			if err != nil {
+				return nil, fmt.Errorf("%w: field %s: %v", ErrInvalidSchema, f.Name, err)
+			}
+			layouts = []string{layout}
+		}
+		return func(s string) (interface{}, error) {
+			if t, ok := inferTime(s, layouts); ok {
+				return t, nil
+			}
+			return nil, fmt.Errorf("invalid %s %q", f.typ(), s)
+		}, nil
+
+	case TypeDuration:
+		if format != "default" {
+			return nil, unsupported
+		}
+		return func(s string) (interface{}, error) { return parseISODuration(s) }, nil
+
+	case TypeObject:
+		if format != "default" {
+			return nil, unsupported
+		}
+		return func(s string) (interface{}, error) {
+			var v map[string]interface{}
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return nil, fmt.Errorf("could not parse %s as a JSON object: %w", s, newJSONError(err))
+			}
+			return v, nil
+		}, nil
+
+	case TypeArray:
+		if format != "default" {
+			return nil, unsupported
+		}
+		return func(s string) (interface{}, error) {
+			var v []interface{}
+			if err := json.Unmarshal([]byte(s), &v); err != nil {
+				return nil, fmt.Errorf("could not parse %s as a JSON array: %w", s, newJSONError(err))
+			}
+			return v, nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w: field %s: unsupported type %q", ErrInvalidSchema, f.Name, f.Type)
+}
+
+// typ returns the type of the field, string if not set.
+func (f *SchemaField) typ() FieldType {
+	if f.Type == "" {
+		return TypeString
+	}
+	return f.Type
+}
+
+// numberFormat returns the number format given by the decimalChar and groupChar of the field.
+func (f *SchemaField) numberFormat() (*NumberFormat, error) {
+	nf := &NumberFormat{}
+	for _, c := range []struct {
+		value string
+		dest  *rune
+	}{
+		{f.DecimalChar, &nf.DecimalSeparator},
+		{f.GroupChar, &nf.GroupSeparator},
+	} {
+		if c.value == "" {
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(c.value)
+		if size != len(c.value) {
+			return nil, fmt.Errorf("%w: field %s: %q is not a single character", ErrInvalidSchema, f.Name, c.value)
+		}
+		*c.dest = r
+	}
+	if err := nf.checkSeparators(); err != nil {
+		return nil, fmt.Errorf("%w: field %s: %v", ErrInvalidSchema, f.Name, err)
+	}
+	return nf, nil
+}
+
+// trimNumber removes the characters around the number in s, e.g. a currency or a percent sign.
+func trimNumber(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '-' && r != '+'
+	})
+}
+
+func parseEmail(s string) (interface{}, error) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" || addr.Address != s {
+		return nil, fmt.Errorf("%q is not an email address", s)
+	}
+	return s, nil
+}
+
+func parseURI(s string) (interface{}, error) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" {
+		return nil, fmt.Errorf("%q is not an absolute URI", s)
+	}
+	return s, nil
+}
+
+// strptimeDirectives are the Go layouts of the strptime directives.
+var strptimeDirectives = map[byte]string{
+	'Y': "2006",
+	'y': "06",
+	'm': "01",
+	'd': "02",
+	'H': "15",
+	'I': "03",
+	'M': "04",
+	'S': "05",
+	'f': "999999999",
+	'p': "PM",
+	'b': "Jan",
+	'B': "January",
+	'a': "Mon",
+	'A': "Monday",
+	'z': "-0700",
+	'Z': "MST",
+	'%': "%",
+}
+
+// strptimeUnpadded are the Go layouts of the directives without zero padding, e.g. %-d.
+var strptimeUnpadded = map[byte]string{
+	'm': "1",
+	'd': "2",
+	'H': "15",
+	'I': "3",
+	'M': "4",
+	'S': "5",
+}
+
+// strptimeLayout returns the Go layout of the strptime pattern, e.g. %d/%m/%Y.
+// The directives prefixed with a dash, e.g. %-d, accept the values without zero padding.
+// The %f directive accepts any number of digits and %:z accepts Z or an offset with a colon.
+func strptimeLayout(pattern string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		if i == len(pattern) {
+			return "", fmt.Errorf("unterminated directive in format %q", pattern)
+		}
+		if strings.HasPrefix(pattern[i:], ":z") {
+			b.WriteString("Z07:00")
+			i++
+			continue
+		}
+		directives := strptimeDirectives
+		if pattern[i] == '-' && i+1 < len(pattern) {
+			directives = strptimeUnpadded
+			i++
+		}
+		layout, ok := directives[pattern[i]]
+		if !ok {
+			return "", fmt.Errorf("unsupported directive %%%c in format %q", pattern[i], pattern)
+		}
+		b.WriteString(layout)
+	}
+	return b.String(), nil
+}
+
+// isoDuration matches an ISO 8601 duration without years and months, e.g. P1DT2H30M.
+var isoDuration = regexp.MustCompile(`^(-)?P(?:([0-9.]+)W)?(?:([0-9.]+)D)?(?:T(?:([0-9.]+)H)?(?:([0-9.]+)M)?(?:([0-9.]+)S)?)?$`)
+
+// parseISODuration returns the duration in the ISO 8601 duration s. The years and
+// months are rejected as they don't have a fixed length.
+func parseISODuration(s string) (time.Duration, error) {
+	match := isoDuration.FindStringSubmatch(s)
+	if match == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid duration %q, expected an ISO 8601 duration without years and months, e.g. P1DT2H", s)
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		part := match[i+2]
+		if part == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		ns := v * float64(unit)
+		if ns >= math.MaxInt64 || d > math.MaxInt64-time.Duration(ns) {
+			return 0, fmt.Errorf("invalid duration %q: %w", s, strconv.ErrRange)
+		}
+		d += time.Duration(ns)
+	}
+	if match[1] != "" {
+		d = -d
+	}
+	return d, nil
+}
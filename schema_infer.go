@@ -0,0 +1,266 @@
+package csvdecoder
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// defaultSampleSize is the number of rows sampled by Infer if none is configured.
+const defaultSampleSize = 1000
+
+// defaultEnumLimit is the maximum number of distinct values of an enum candidate if none is configured.
+const defaultEnumLimit = 10
+
+// InferOptions configures the inference of a schema by Infer.
+type InferOptions struct {
+	Config        Config   // the configuration of the decoder reading the sample, e.g. the delimiter. If IgnoreHeaders is false, the fields are named field1, field2...
+	SampleSize    int      // the maximum number of rows sampled. Default value is 1000.
+	MinConfidence float64  // the minimum ratio of the values conforming to a type for the type to be inferred. Default value is 1: every value must conform.
+	EnumLimit     int      // the maximum number of distinct values of an enum candidate. Default value is 10. A negative value disables the enums.
+	MissingValues []string // the values standing for missing values. Default value is the empty string.
+}
+
+// FieldStats are the statistics of the sampled values of a field, computed by Infer.
+type FieldStats struct {
+	Count      int     `json:"count"`                // the number of sampled values
+	Missing    int     `json:"missing"`              // the number of missing values
+	Distinct   int     `json:"distinct"`             // the number of distinct values, missing values excluded
+	Unique     bool    `json:"unique"`               // the values are all different, missing values excluded
+	Min        string  `json:"min,omitempty"`        // the minimum value of an integer, number, date or time, as written in the file
+	Max        string  `json:"max,omitempty"`        // the maximum value of an integer, number, date or time, as written in the file
+	MinLength  int     `json:"minLength"`            // the minimum length of the values in characters
+	MaxLength  int     `json:"maxLength"`            // the maximum length of the values in characters
+	Confidence float64 `json:"confidence"`           // the ratio of the values conforming to the type of the field, missing values excluded
+	EnumValues int     `json:"enumValues,omitempty"` // the number of allowed values if the field is an enum candidate
+}
+
+// Nullable reports whether some sampled values are missing.
+func (s *FieldStats) Nullable() bool {
+	return s.Missing > 0
+}
+
+// inferCandidates are the types tried in order for the fields. The first type with
+// enough conforming values is inferred. The dates and times are tried with the
+// common formats, the ambiguous ones in the order of the list.
+var inferCandidates = []SchemaField{
+	{Type: TypeInteger},
+	{Type: TypeNumber},
+	{Type: TypeBoolean},
+	{Type: TypeBoolean, TrueValues: []string{"yes", "Yes", "YES", "y", "Y"}, FalseValues: []string{"no", "No", "NO", "n", "N"}},
+	{Type: TypeDate},
+	{Type: TypeDate, Format: "%d/%m/%Y"},
+	{Type: TypeDate, Format: "%m/%d/%Y"},
+	{Type: TypeDate, Format: "%d.%m.%Y"},
+	{Type: TypeDate, Format: "%Y/%m/%d"},
+	{Type: TypeDatetime},
+	{Type: TypeDatetime, Format: "%Y-%m-%dT%H:%M:%S"},
+	{Type: TypeDatetime, Format: "%Y-%m-%d %H:%M:%S"},
+	{Type: TypeDatetime, Format: "%Y-%m-%d %H:%M"},
+	{Type: TypeDatetime, Format: "%d/%m/%Y %H:%M:%S"},
+	{Type: TypeDatetime, Format: "%d/%m/%Y %H:%M"},
+	{Type: TypeTime},
+	{Type: TypeTime, Format: "%H:%M"},
+}
+
+// Infer returns a schema inferred from a sample of the rows read from r. The type of
+// every field is the first of integer, number, boolean, date, datetime and time with a
+// detected format that enough sampled values conform to, or string. A string field
+// with few distinct values repeated in the sample is an enum candidate: its values
+// are listed in the enum constraint. A field without missing values is required.
+//
+// The statistics of the sampled values are given in the Stats of the fields, e.g.
+// to seed further constraints, and are written with the schema.
+func Infer(r io.Reader, opts InferOptions) (*Schema, error) {
+	if opts.SampleSize <= 0 {
+		opts.SampleSize = defaultSampleSize
+	}
+	if opts.MinConfidence <= 0 {
+		opts.MinConfidence = 1
+	}
+	if opts.EnumLimit == 0 {
+		opts.EnumLimit = defaultEnumLimit
+	}
+	missing := map[string]bool{"": true}
+	if opts.MissingValues != nil {
+		missing = make(map[string]bool, len(opts.MissingValues))
+		for _, v := range opts.MissingValues {
+			missing[v] = true
+		}
+	}
+
+	d, err := NewWithConfig(r, opts.Config)
+	if err != nil {
+		return nil, err
+	}
+	var columns [][]string
+	for rows := 0; rows < opts.SampleSize && d.Next(); rows++ {
+		values := d.row().Values()
+		for len(columns) < len(values) {
+			// the values of the previous rows are missing in the new columns
+			columns = append(columns, make([]string, rows))
+		}
+		for i := range columns {
+			var v string
+			if i < len(values) {
+				v = values[i]
+			}
+			columns[i] = append(columns[i], v)
+		}
+	}
+	if err := d.Err(); err != nil {
+		return nil, err
+	}
+
+	header := d.Header()
+	for len(columns) < len(header) {
+		columns = append(columns, nil)
+	}
+	s := &Schema{}
+	if opts.MissingValues != nil {
+		s.MissingValues = opts.MissingValues
+	}
+	for i, values := range columns {
+		name := "field" + strconv.Itoa(i+1)
+		if i < len(header) {
+			name = header[i]
+		}
+		f, err := inferField(name, values, missing, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.Fields = append(s.Fields, f)
+	}
+	return s, nil
+}
+
+// inferField returns the field inferred from its sampled values.
+func inferField(name string, values []string, missing map[string]bool, opts InferOptions) (SchemaField, error) {
+	stats := &FieldStats{Count: len(values)}
+	distinct := make(map[string]bool)
+	var present []string
+	for _, v := range values {
+		if missing[v] {
+			stats.Missing++
+			continue
+		}
+		present = append(present, v)
+		distinct[v] = true
+
+		l := utf8.RuneCountInString(v)
+		if len(present) == 1 || l < stats.MinLength {
+			stats.MinLength = l
+		}
+		if l > stats.MaxLength {
+			stats.MaxLength = l
+		}
+	}
+	stats.Distinct = len(distinct)
+	stats.Unique = len(present) > 0 && len(distinct) == len(present)
+
+	f := SchemaField{Name: name, Type: TypeString}
+	stats.Confidence = 1
+	if len(present) > 0 {
+		for _, candidate := range inferCandidates {
+			conforming, min, max, err := conformance(candidate, present, opts.Config)
+			if err != nil {
+				return f, err
+			}
+			confidence := float64(conforming) / float64(len(present))
+			if confidence >= opts.MinConfidence {
+				candidate = configuredCandidate(candidate, opts.Config)
+				f.Type, f.Format = candidate.Type, candidate.Format
+				f.TrueValues, f.FalseValues = candidate.TrueValues, candidate.FalseValues
+				f.DecimalChar, f.GroupChar = candidate.DecimalChar, candidate.GroupChar
+				stats.Confidence, stats.Min, stats.Max = confidence, min, max
+				break
+			}
+		}
+	}
+
+	if stats.Missing == 0 && len(values) > 0 {
+		f.Constraints = &Constraints{Required: true}
+	}
+	if f.Type == TypeString && opts.EnumLimit > 0 && stats.Distinct > 0 &&
+		stats.Distinct <= opts.EnumLimit && len(present) >= 2*stats.Distinct {
+		enum := make([]string, 0, len(distinct))
+		for v := range distinct {
+			enum = append(enum, v)
+		}
+		sort.Strings(enum)
+		if f.Constraints == nil {
+			f.Constraints = &Constraints{}
+		}
+		for _, v := range enum {
+			raw, _ := json.Marshal(v)
+			f.Constraints.Enum = append(f.Constraints.Enum, raw)
+		}
+		stats.EnumValues = len(enum)
+	}
+	f.Stats = stats
+	return f, nil
+}
+
+// configuredCandidate returns the candidate field reading the numbers in the
+// number format of the configuration.
+func configuredCandidate(candidate SchemaField, config Config) SchemaField {
+	if nf := config.NumberFormat; nf != nil && (candidate.Type == TypeNumber || candidate.Type == TypeInteger) {
+		if nf.DecimalSeparator != 0 {
+			candidate.DecimalChar = string(nf.DecimalSeparator)
+		}
+		if nf.GroupSeparator != 0 {
+			candidate.GroupChar = string(nf.GroupSeparator)
+		}
+	}
+	return candidate
+}
+
+// candidateParser returns the parser of the candidate field, reading the numbers
+// in the number format of the configuration.
+func candidateParser(candidate SchemaField, config Config) (parseFunc, error) {
+	candidate = configuredCandidate(candidate, config)
+	return candidate.parser(config)
+}
+
+// conformance returns the number of values conforming to the type of the candidate field,
+// and the minimum and maximum conforming values if the type is ordered.
+func conformance(candidate SchemaField, values []string, config Config) (int, string, string, error) {
+	parse, err := candidateParser(candidate, config)
+	if err != nil {
+		return 0, "", "", err
+	}
+
+	conforming := 0
+	var min, max string
+	var minValue, maxValue reflect.Value
+	for _, s := range values {
+		if (candidate.Type == TypeInteger || candidate.Type == TypeNumber) && hasLeadingZero(s) {
+			// usually an identifier, e.g. a zip code
+			continue
+		}
+		v, err := parse(s)
+		if err != nil {
+			continue
+		}
+		conforming++
+
+		rv := reflect.ValueOf(v)
+		if !isOrdered(rv.Type()) || rv.Kind() == reflect.String {
+			continue
+		}
+		if !minValue.IsValid() {
+			min, minValue, max, maxValue = s, rv, s, rv
+			continue
+		}
+		if c, ok := compare(rv, minValue); ok && c < 0 {
+			min, minValue = s, rv
+		}
+		if c, ok := compare(rv, maxValue); ok && c > 0 {
+			max, maxValue = s, rv
+		}
+	}
+	return conforming, min, max, nil
+}
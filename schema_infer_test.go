@@ -0,0 +1,204 @@
+package csvdecoder
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const inferSample = `id,zip,price,active,joined,updated,status,note
+1,01234,9.5,yes,31/01/2020,2020-01-31 10:00,open,
+2,02345,10,no,01/02/2020,2020-02-01 11:30,closed,late
+3,03456,12.25,yes,15/02/2020,2020-02-15 09:15,open,
+4,04567,8,yes,29/02/2020,2020-02-29 18:45,open,early
+`
+
+func TestInfer(t *testing.T) {
+	s, err := Infer(strings.NewReader(inferSample), InferOptions{Config: Config{IgnoreHeaders: true}})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+	if len(s.Fields) != 8 {
+		t.Fatalf("expected 8 fields, got %+v", s.Fields)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		typ    FieldType
+		format string
+	}{
+		{"id", TypeInteger, ""},
+		{"zip", TypeString, ""},
+		{"price", TypeNumber, ""},
+		{"active", TypeBoolean, ""},
+		{"joined", TypeDate, "%d/%m/%Y"},
+		{"updated", TypeDatetime, "%Y-%m-%d %H:%M"},
+		{"status", TypeString, ""},
+		{"note", TypeString, ""},
+	} {
+		f := s.Field(tc.name)
+		if f == nil || f.Type != tc.typ || f.Format != tc.format {
+			t.Errorf("expected field %s of type %s and format %q, got %+v", tc.name, tc.typ, tc.format, f)
+		}
+	}
+
+	id := s.Field("id")
+	if id.Constraints == nil || !id.Constraints.Required {
+		t.Errorf("expected id to be required, got %+v", id.Constraints)
+	}
+	if st := id.Stats; st.Min != "1" || st.Max != "4" || !st.Unique || st.Distinct != 4 || st.Confidence != 1 || st.Nullable() {
+		t.Errorf("unexpected stats of id %+v", st)
+	}
+	if st := s.Field("price").Stats; st.Min != "8" || st.Max != "12.25" {
+		t.Errorf("unexpected stats of price %+v", st)
+	}
+	if st := s.Field("joined").Stats; st.Min != "31/01/2020" || st.Max != "29/02/2020" {
+		t.Errorf("unexpected stats of joined %+v", st)
+	}
+
+	status := s.Field("status")
+	if status.Constraints == nil || len(status.Constraints.Enum) != 2 ||
+		string(status.Constraints.Enum[0]) != `"closed"` || string(status.Constraints.Enum[1]) != `"open"` {
+		t.Errorf("expected status to be an enum candidate, got %+v", status.Constraints)
+	}
+	if status.Stats.EnumValues != 2 || status.Stats.Unique {
+		t.Errorf("unexpected stats of status %+v", status.Stats)
+	}
+
+	note := s.Field("note")
+	if note.Constraints != nil {
+		t.Errorf("expected note to be optional, got %+v", note.Constraints)
+	}
+	if st := note.Stats; !st.Nullable() || st.Missing != 2 || st.Count != 4 || st.MinLength != 4 || st.MaxLength != 5 {
+		t.Errorf("unexpected stats of note %+v", st)
+	}
+
+	// the inferred schema decodes the sample
+	d, err := NewWithConfig(strings.NewReader(inferSample), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	for d.Next() {
+		if _, err := d.DecodeSchema(s); err != nil {
+			t.Errorf("could not decode with the inferred schema: %s", err)
+		}
+	}
+	if err := d.Err(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestInferOptions(t *testing.T) {
+	data := "1;a\n2;b\nx;a\n4;b\n-;a\n"
+	s, err := Infer(strings.NewReader(data), InferOptions{
+		Config:        Config{Comma: ';'},
+		MinConfidence: 0.7,
+		EnumLimit:     -1,
+		MissingValues: []string{"-"},
+	})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+	if len(s.Fields) != 2 || s.Fields[0].Name != "field1" || s.Fields[1].Name != "field2" {
+		t.Fatalf("unexpected fields %+v", s.Fields)
+	}
+	if !reflect.DeepEqual(s.MissingValues, []string{"-"}) {
+		t.Errorf("expected the missing values in the schema, got %v", s.MissingValues)
+	}
+	f := s.Fields[0]
+	if f.Type != TypeInteger || f.Stats.Confidence != 0.75 || f.Stats.Missing != 1 || f.Constraints != nil {
+		t.Errorf("unexpected field %+v with stats %+v", f, f.Stats)
+	}
+	if f := s.Fields[1]; f.Type != TypeString || f.Constraints == nil || f.Constraints.Enum != nil {
+		t.Errorf("expected a required string without enum, got %+v", f)
+	}
+
+	s, err = Infer(strings.NewReader(data), InferOptions{Config: Config{Comma: ';'}, SampleSize: 2})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+	if f := s.Fields[0]; f.Type != TypeInteger || f.Stats.Count != 2 {
+		t.Errorf("expected the first 2 rows to be sampled, got %+v with stats %+v", f, f.Stats)
+	}
+}
+
+func TestInferNumberFormat(t *testing.T) {
+	data := "count;price\n1.234;1.234,5\n2;0,75\n"
+	s, err := Infer(strings.NewReader(data), InferOptions{Config: Config{
+		Comma:         ';',
+		IgnoreHeaders: true,
+		NumberFormat:  &NumberFormat{DecimalSeparator: ',', GroupSeparator: '.'},
+	}})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+	for _, f := range s.Fields {
+		if f.DecimalChar != "," || f.GroupChar != "." {
+			t.Errorf("expected the number format in field %+v", f)
+		}
+	}
+
+	// the schema decodes the file without the number format in the configuration
+	d, err := NewWithConfig(strings.NewReader(data), Config{Comma: ';', IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+	var got []map[string]interface{}
+	for d.Next() {
+		m, err := d.DecodeSchema(s)
+		if err != nil {
+			t.Fatalf("could not decode with the inferred schema: %s", err)
+		}
+		got = append(got, m)
+	}
+	expected := []map[string]interface{}{
+		{"count": int64(1234), "price": 1234.5},
+		{"count": int64(2), "price": 0.75},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, got)
+	}
+}
+
+func TestInferReadsSample(t *testing.T) {
+	errReadTooFar := errors.New("read past the sample")
+	data := "id,name\n" + strings.Repeat("1,a\n", 10000)
+	in := io.MultiReader(strings.NewReader(data), iotest.ErrReader(errReadTooFar))
+
+	s, err := Infer(in, InferOptions{Config: Config{IgnoreHeaders: true}, SampleSize: 10})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+	if len(s.Fields) != 2 || s.Fields[0].Stats.Count != 10 {
+		t.Errorf("unexpected fields %+v", s.Fields)
+	}
+}
+
+func TestInferWrite(t *testing.T) {
+	s, err := Infer(strings.NewReader(inferSample), InferOptions{Config: Config{IgnoreHeaders: true}})
+	if err != nil {
+		t.Fatalf("could not infer the schema: %s", err)
+	}
+	var buf bytes.Buffer
+	if err := s.Write(&buf); err != nil {
+		t.Fatalf("could not write the schema: %s", err)
+	}
+	if !strings.Contains(buf.String(), `"confidence": 1`) {
+		t.Errorf("expected the stats in the JSON, got %s", buf.String())
+	}
+
+	read, err := ReadSchema(&buf)
+	if err != nil {
+		t.Fatalf("could not read the schema: %s", err)
+	}
+	if !reflect.DeepEqual(read, s) {
+		a, _ := json.Marshal(s)
+		b, _ := json.Marshal(read)
+		t.Errorf("expected %s, got %s", a, b)
+	}
+}
@@ -0,0 +1,251 @@
+package csvdecoder
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testTableSchema = `{
+  "fields": [
+    {"name": "id", "type": "integer"},
+    {"name": "email", "type": "string", "format": "email", "constraints": {"required": true, "unique": true}},
+    {"name": "status", "constraints": {"enum": ["active", "inactive"]}},
+    {"name": "score", "type": "number", "decimalChar": ",", "groupChar": ".", "constraints": {"minimum": 0, "maximum": "1.000"}},
+    {"name": "active", "type": "boolean", "trueValues": ["y"], "falseValues": ["n"]},
+    {"name": "joined", "type": "date", "format": "%d/%m/%Y", "constraints": {"minimum": "01/01/2000"}},
+    {"name": "timeout", "type": "duration"},
+    {"name": "code", "constraints": {"pattern": "[A-Z]{3}", "minLength": 3}},
+    {"name": "tags", "type": "array"}
+  ],
+  "primaryKey": "id",
+  "missingValues": ["", "NA"]
+}`
+
+func TestReadSchema(t *testing.T) {
+	s, err := ReadSchema(strings.NewReader(testTableSchema))
+	if err != nil {
+		t.Fatalf("could not read the schema: %s", err)
+	}
+	if len(s.Fields) != 9 || !reflect.DeepEqual(s.PrimaryKey, FieldNames{"id"}) {
+		t.Errorf("unexpected schema %+v", s)
+	}
+	if f := s.Field("joined"); f == nil || f.Type != TypeDate || f.Format != "%d/%m/%Y" {
+		t.Errorf("unexpected field %+v", f)
+	}
+
+	var buf bytes.Buffer
+	if err := s.Write(&buf); err != nil {
+		t.Fatalf("could not write the schema: %s", err)
+	}
+	written, err := ReadSchema(&buf)
+	if err != nil {
+		t.Fatalf("could not read the written schema: %s", err)
+	}
+	if !reflect.DeepEqual(written, s) {
+		t.Errorf("expected schema '%+v' got '%+v'", s, written)
+	}
+}
+
+func TestReadSchemaInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		schema string
+	}{
+		{name: "should reject invalid JSON", schema: `{"fields": [`},
+		{name: "should reject an unknown type", schema: `{"fields": [{"name": "a", "type": "geopoint"}]}`},
+		{name: "should reject an unknown format", schema: `{"fields": [{"name": "a", "format": "phone"}]}`},
+		{name: "should reject an invalid date format", schema: `{"fields": [{"name": "a", "type": "date", "format": "%Q"}]}`},
+		{name: "should reject an invalid bound", schema: `{"fields": [{"name": "a", "type": "integer", "constraints": {"minimum": "x"}}]}`},
+		{name: "should reject an invalid pattern", schema: `{"fields": [{"name": "a", "constraints": {"pattern": "[a-"}}]}`},
+		{name: "should reject the same decimal and group characters", schema: `{"fields": [{"name": "a", "type": "number", "decimalChar": ",", "groupChar": ","}]}`},
+		{name: "should reject an unknown primary key", schema: `{"fields": [{"name": "a"}], "primaryKey": ["b"]}`},
+		{name: "should reject duplicate fields", schema: `{"fields": [{"name": "a"}, {"name": "a"}]}`},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := ReadSchema(strings.NewReader(tc.schema)); !errors.Is(err, ErrInvalidSchema) {
+				t.Errorf("expected error %v got %v", ErrInvalidSchema, err)
+			}
+		})
+	}
+}
+
+func TestDecodeSchema(t *testing.T) {
+	s, err := ReadSchema(strings.NewReader(testTableSchema))
+	if err != nil {
+		t.Fatalf("could not read the schema: %s", err)
+	}
+
+	data := "id,email,status,score,active,joined,timeout,code,tags\n" +
+		"1,jo@example.com,active,\"1.000,0\",y,15/03/2021,PT1H30M,ABC,\"[1,2]\"\n" +
+		"2,al@example.com,NA,\"0,5\",n,01/01/2000,P1DT2H,,\n"
+	d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	expected := []map[string]interface{}{
+		{
+			"id": int64(1), "email": "jo@example.com", "status": "active", "score": 1000.0, "active": true,
+			"joined": time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC), "timeout": 90 * time.Minute, "code": "ABC",
+			"tags": []interface{}{1.0, 2.0},
+		},
+		{
+			"id": int64(2), "email": "al@example.com", "status": nil, "score": 0.5, "active": false,
+			"joined": time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), "timeout": 26 * time.Hour, "code": nil,
+			"tags": nil,
+		},
+	}
+	var got []map[string]interface{}
+	for d.Next() {
+		m, err := d.DecodeSchema(s)
+		if err != nil {
+			t.Error(err)
+		}
+		got = append(got, m)
+	}
+	if d.Err() != nil {
+		t.Error(d.Err())
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, got)
+	}
+}
+
+func TestDecodeSchemaConstraints(t *testing.T) {
+	s, err := ReadSchema(strings.NewReader(testTableSchema))
+	if err != nil {
+		t.Fatalf("could not read the schema: %s", err)
+	}
+	const valid = "1,jo@example.com,active,\"0,5\",y,15/03/2021,PT1H,ABC,[]"
+
+	for _, tc := range []struct {
+		name   string
+		rows   []string
+		column string
+		err    error
+	}{
+		{
+			name:   "should reject a missing required value",
+			rows:   []string{strings.Replace(valid, "jo@example.com", "NA", 1)},
+			column: "email",
+			err:    ErrValidation,
+		},
+		{
+			name:   "should reject a value that is not allowed",
+			rows:   []string{strings.Replace(valid, "active", "gone", 1)},
+			column: "status",
+			err:    ErrNotAllowed,
+		},
+		{
+			name:   "should reject a number greater than the maximum",
+			rows:   []string{strings.Replace(valid, "\"0,5\"", "\"1.000,5\"", 1)},
+			column: "score",
+			err:    ErrValidation,
+		},
+		{
+			name:   "should reject a date less than the minimum",
+			rows:   []string{strings.Replace(valid, "15/03/2021", "31/12/1999", 1)},
+			column: "joined",
+			err:    ErrValidation,
+		},
+		{
+			name:   "should reject a value not matching the whole pattern",
+			rows:   []string{strings.Replace(valid, "ABC", "ABCD", 1)},
+			column: "code",
+			err:    ErrValidation,
+		},
+		{
+			name:   "should reject a duplicate unique value",
+			rows:   []string{valid, strings.Replace(valid, "1,", "2,", 1)},
+			column: "email",
+			err:    ErrValidation,
+		},
+		{
+			name:   "should reject a duplicate primary key",
+			rows:   []string{valid, strings.Replace(valid, "jo@", "al@", 1)},
+			column: "id",
+			err:    ErrValidation,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			data := "id,email,status,score,active,joined,timeout,code,tags\n" + strings.Join(tc.rows, "\n") + "\n"
+			d, err := NewWithConfig(strings.NewReader(data), Config{IgnoreHeaders: true})
+			if err != nil {
+				t.Fatalf("could not create d: %s", err)
+			}
+
+			var last error
+			for d.Next() {
+				_, last = d.DecodeSchema(s)
+			}
+			if d.Err() != nil {
+				t.Error(d.Err())
+			}
+
+			var fieldErr *FieldError
+			if !errors.As(last, &fieldErr) || fieldErr.Column != tc.column || fieldErr.Row != len(tc.rows)+1 {
+				t.Errorf("expected a field error on column %q, got %v", tc.column, last)
+			}
+			if !errors.Is(last, tc.err) {
+				t.Errorf("expected error %v got %v", tc.err, last)
+			}
+		})
+	}
+}
+
+func TestDecodeSchemaByPosition(t *testing.T) {
+	s := &Schema{Fields: []SchemaField{
+		{Name: "name"},
+		{Name: "age", Type: TypeInteger, Constraints: &Constraints{Required: true}},
+	}}
+
+	d, err := NewWithConfig(strings.NewReader("jo,42\nal\n"), Config{})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	var results []map[string]interface{}
+	var errs []error
+	for d.Next() {
+		m, err := d.DecodeSchema(s)
+		results = append(results, m)
+		errs = append(errs, err)
+	}
+	if expected := map[string]interface{}{"name": "jo", "age": int64(42)}; !reflect.DeepEqual(results[0], expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, results[0])
+	}
+	if !errors.Is(errs[1], ErrScanTargetsNotMatch) {
+		t.Errorf("expected error %v got %v", ErrScanTargetsNotMatch, errs[1])
+	}
+}
+
+func TestDecodeSchemaFormats(t *testing.T) {
+	s := &Schema{Fields: []SchemaField{
+		{Name: "count", Type: TypeInteger, GroupChar: "'"},
+		{Name: "at", Type: TypeDatetime, Format: "%Y-%m-%d %H:%M:%S.%f"},
+	}}
+
+	d, err := NewWithConfig(strings.NewReader("count,at\n1'234'567,2021-03-15 10:30:00.123\n"), Config{IgnoreHeaders: true})
+	if err != nil {
+		t.Fatalf("could not create d: %s", err)
+	}
+
+	d.Next()
+	got, err := d.DecodeSchema(s)
+	if err != nil {
+		t.Fatalf("could not decode: %s", err)
+	}
+	expected := map[string]interface{}{
+		"count": int64(1234567),
+		"at":    time.Date(2021, 3, 15, 10, 30, 0, 123000000, time.UTC),
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected value '%v' got '%v'", expected, got)
+	}
+}
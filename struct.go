@@ -0,0 +1,570 @@
+package csvdecoder
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// tagKey is the key of the struct tag read by Decode.
+const tagKey = "csv"
+
+var (
+	recordDecoderType    = reflect.TypeOf((*RecordDecoder)(nil)).Elem()
+	interfaceType        = reflect.TypeOf((*Interface)(nil)).Elem()
+	contextInterfaceType = reflect.TypeOf((*ContextInterface)(nil)).Elem()
+)
+
+// convertibleStructs are the struct types converted from a single field by convertAssignValue.
+// They are never flattened.
+var convertibleStructs = map[reflect.Type]bool{
+	reflect.TypeOf(big.Int{}):        true,
+	reflect.TypeOf(big.Float{}):      true,
+	reflect.TypeOf(big.Rat{}):        true,
+	reflect.TypeOf(net.IPNet{}):      true,
+	reflect.TypeOf(netip.Addr{}):     true,
+	reflect.TypeOf(netip.AddrPort{}): true,
+	reflect.TypeOf(netip.Prefix{}):   true,
+	reflect.TypeOf(url.URL{}):        true,
+	reflect.TypeOf(mail.Address{}):   true,
+	reflect.TypeOf(time.Time{}):      true,
+}
+
+// fieldTag holds the parsed `csv` struct tag of a field.
+// The tag has the form `csv:"name,option,option=value"`. The value of the
+// regex option is the rest of the tag, so that the expression can contain commas.
+type fieldTag struct {
+	name    string
+	options TagOptions
+}
+
+func parseTag(tag string) fieldTag {
+	parts := strings.Split(tag, ",")
+	t := fieldTag{
+		name:    strings.TrimSpace(parts[0]),
+		options: make(TagOptions, len(parts)-1),
+	}
+	for j, opt := range parts[1:] {
+		opt = strings.TrimSpace(opt)
+		if opt == "" {
+			continue
+		}
+		if i := strings.IndexByte(opt, '='); i >= 0 {
+			if opt[:i] == "regex" {
+				t.options["regex"] = strings.TrimSpace(strings.Join(append([]string{opt[i+1:]}, parts[j+2:]...), ","))
+				break
+			}
+			t.options[opt[:i]] = opt[i+1:]
+		} else {
+			t.options[opt] = ""
+		}
+	}
+	return t
+}
+
+// fieldMapping describes how a struct field is decoded from a row.
+type fieldMapping struct {
+	index   []int  // the index sequence of the field, as used by reflect.Value.FieldByIndex
+	path    string // the path of the field in the struct, e.g. Address.City
+	depth   int    // the nesting depth of the field, used to resolve conflicts like Go promotes fields
+	name    string // the name of the column, or the name of the field if it spans several columns
+	columns []int  // the indexes of the columns in the header. nil if the field is given the whole row
+	record  bool   // the type of the field implements RecordDecoder
+	rest    bool   // the field receives the columns not matched by other fields
+	group   *groupMapping
+	options TagOptions
+	combine CombineFunc
+	rules   []rule // the validation rules, only for fields decoded from a single column
+
+	// defaultValue replaces the empty fields, only for fields decoded from a single column.
+	// If the column is missing, columns is empty and the field is always given the default.
+	defaultValue *string
+	// nulls are the values decoded like an empty field, only for fields decoded from a single column.
+	nulls []string
+}
+
+// structMapping describes how a struct type is decoded from the rows of a file.
+type structMapping struct {
+	fields []fieldMapping
+}
+
+// mappingBuilder collects the fields of a struct type, including the fields
+// of embedded and prefixed nested structs, and matches them with the header.
+type mappingBuilder struct {
+	header   []string
+	config   Config
+	fields   []fieldMapping
+	rest     *fieldMapping
+	visiting map[reflect.Type]bool
+	// defaulted are the keys of Config.Defaults naming a field
+	defaulted map[string]bool
+}
+
+// newStructMapping matches the fields of the struct type t with the columns in header.
+func newStructMapping(t reflect.Type, header []string, config Config) (*structMapping, error) {
+	b := &mappingBuilder{
+		header:   header,
+		config:   config,
+		visiting: make(map[reflect.Type]bool),
+	}
+	if err := b.collect(t, "", "", nil, 0); err != nil {
+		return nil, err
+	}
+	if err := b.checkDefaults(); err != nil {
+		return nil, err
+	}
+	return b.resolve()
+}
+
+// collect adds the fields of the struct type t to the builder. The column names of the
+// fields are prefixed with prefix and their index sequences start with index.
+func (b *mappingBuilder) collect(t reflect.Type, prefix, path string, index []int, depth int) error {
+	if b.visiting[t] {
+		return fmt.Errorf("%w: field %s: recursive struct type %s", ErrInvalidTag, path, t)
+	}
+	b.visiting[t] = true
+	defer delete(b.visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := parseTag(sf.Tag.Get(tagKey))
+		if tag.name == "-" {
+			continue
+		}
+
+		fieldIndex := append(index[:len(index):len(index)], i)
+		fieldPath := sf.Name
+		if path != "" {
+			fieldPath = path + "." + sf.Name
+		}
+
+		st := sf.Type
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		nestedPrefix, hasPrefix := tag.options["prefix"]
+		if hasPrefix && (st.Kind() != reflect.Struct || b.isDecodable(sf.Type)) {
+			return fmt.Errorf("%w: field %s: prefix requires a struct type", ErrInvalidTag, fieldPath)
+		}
+		promote := sf.Anonymous && tag.name == "" && st.Kind() == reflect.Struct && !b.isDecodable(sf.Type)
+		if hasPrefix || promote {
+			if sf.PkgPath != "" && sf.Type.Kind() == reflect.Ptr {
+				// unexported embedded pointers can't be allocated
+				continue
+			}
+			if err := b.collect(st, prefix+nestedPrefix, fieldPath, fieldIndex, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			// unexported field
+			continue
+		}
+
+		if tag.options.Has("rest") {
+			if err := b.setRest(sf.Type, fieldPath, fieldIndex); err != nil {
+				return err
+			}
+			continue
+		}
+
+		f := fieldMapping{
+			index:   fieldIndex,
+			path:    fieldPath,
+			depth:   depth,
+			name:    tag.name,
+			record:  isRecordDecoder(sf.Type),
+			options: tag.options,
+		}
+		if f.name == "" {
+			f.name = sf.Name
+		}
+		f.name = prefix + f.name
+
+		rules, err := compileRules(t, index, sf, fieldPath, tag.options)
+		if err != nil {
+			return err
+		}
+		if len(rules) > 0 && (f.record || tag.options.Has("pattern") || tag.options.Has("columns")) {
+			return fmt.Errorf("%w: field %s: validation rules require a field decoded from a single column", ErrInvalidTag, fieldPath)
+		}
+		f.rules = rules
+		if bf := boolFormat(&FieldContext{Options: tag.options}); bf != nil {
+			if err := bf.checkTokens(); err != nil {
+				return fmt.Errorf("%w: field %s: %v", ErrInvalidTag, fieldPath, err)
+			}
+		}
+		if err := f.setNull(); err != nil {
+			return err
+		}
+		if err := b.setDefault(&f, sf.Type); err != nil {
+			return err
+		}
+
+		aliases, hasAlias := tag.options["alias"]
+		if hasAlias && (f.record || tag.options.Has("pattern") || tag.options.Has("columns")) {
+			return fmt.Errorf("%w: field %s: alias requires a field decoded from a single column", ErrInvalidTag, fieldPath)
+		}
+
+		if pattern, ok := tag.options["pattern"]; ok {
+			if err := b.matchGroups(&f, sf.Type, prefix, pattern); err != nil {
+				return err
+			}
+			b.fields = append(b.fields, f)
+			continue
+		}
+
+		cols, hasColumns := tag.options["columns"]
+		combineName, hasCombine := tag.options["combine"]
+		switch {
+		case hasCombine && !hasColumns:
+			return fmt.Errorf("%w: field %s: combine requires the columns option", ErrInvalidTag, fieldPath)
+		case hasCombine:
+			f.combine = b.config.combiners[combineName]
+			if f.combine == nil {
+				return fmt.Errorf("%w: field %s: no combiner registered as %q", ErrInvalidTag, fieldPath, combineName)
+			}
+		case hasColumns && !f.record:
+			return fmt.Errorf("%w: field %s: columns requires the combine option or a RecordDecoder", ErrInvalidTag, fieldPath)
+		case f.record && !hasColumns:
+			// the field is given the whole row
+			b.fields = append(b.fields, f)
+			continue
+		case hasAlias:
+			cols = b.aliasColumn(&f, prefix, aliases)
+		case !hasColumns:
+			cols = f.name
+		}
+
+		ok, err := b.matchColumns(&f, prefix, cols)
+		if err != nil {
+			return err
+		}
+		if ok {
+			b.fields = append(b.fields, f)
+		}
+	}
+	return nil
+}
+
+// setRest sets the field receiving the columns not matched by other fields.
+func (b *mappingBuilder) setRest(t reflect.Type, path string, index []int) error {
+	if b.rest != nil {
+		return fmt.Errorf("%w: fields %s and %s are both tagged with rest", ErrInvalidTag, b.rest.path, path)
+	}
+	isString := func(t reflect.Type) bool { return t.Kind() == reflect.String }
+	switch {
+	case t.Kind() == reflect.Map && isString(t.Key()) && isString(t.Elem()):
+	case t.Kind() == reflect.Slice && isString(t.Elem()):
+	default:
+		return fmt.Errorf("%w: field %s: rest requires a map[string]string or a []string, got %s", ErrInvalidTag, path, t)
+	}
+	b.rest = &fieldMapping{
+		index: index,
+		path:  path,
+		name:  path,
+		rest:  true,
+	}
+	return nil
+}
+
+// matchColumns sets the columns of f to the columns listed in cols, separated by '|'.
+// It returns false if a column is missing and the unmatching fields are ignored.
+func (b *mappingBuilder) matchColumns(f *fieldMapping, prefix, cols string) (bool, error) {
+	if f.combine != nil || f.record {
+		// the columns listed in the tag are relative to the prefix of the struct
+		prefixed := strings.Split(cols, "|")
+		for i := range prefixed {
+			prefixed[i] = prefix + prefixed[i]
+		}
+		cols = strings.Join(prefixed, "|")
+	}
+
+	f.columns = make([]int, 0, strings.Count(cols, "|")+1)
+	for _, col := range strings.Split(cols, "|") {
+		idx, err := columnIndex(b.header, col)
+		if err != nil {
+			return false, fmt.Errorf("field %s: %w", f.path, err)
+		}
+		if idx < 0 {
+			if f.defaultValue != nil {
+				f.columns = f.columns[:0]
+				return true, nil
+			}
+			if b.config.IgnoreUnmatchingFields {
+				return false, nil
+			}
+			return false, fmt.Errorf("%w: no column %q for field %s", ErrScanTargetsNotMatch, col, f.path)
+		}
+		f.columns = append(f.columns, idx)
+	}
+	return true, nil
+}
+
+// aliasColumn returns the name of the column of the field f: its name if the header has
+// such a column, else the first of the aliases, separated by '|', found in the header.
+// The aliases are prefixed with the prefix of the struct of the field.
+func (b *mappingBuilder) aliasColumn(f *fieldMapping, prefix, aliases string) string {
+	if idx, err := columnIndex(b.header, f.name); idx >= 0 || err != nil {
+		// an ambiguous name is reported when the columns are matched
+		return f.name
+	}
+	for _, alias := range strings.Split(aliases, "|") {
+		if idx, err := columnIndex(b.header, prefix+alias); idx >= 0 || err != nil {
+			return prefix + alias
+		}
+	}
+	return f.name
+}
+
+// resolve removes the fields hidden by a less nested field matching the same
+// column by name and checks that every column is matched.
+func (b *mappingBuilder) resolve() (*structMapping, error) {
+	owners := make([][]int, len(b.header))
+	for i, f := range b.fields {
+		if f.combine != nil || f.record || f.group != nil {
+			// the columns listed explicitly in the tag may be shared
+			continue
+		}
+		for _, idx := range f.columns {
+			owners[idx] = append(owners[idx], i)
+		}
+	}
+
+	hidden := make([]bool, len(b.fields))
+	for idx, fields := range owners {
+		if len(fields) < 2 {
+			continue
+		}
+		minDepth := b.fields[fields[0]].depth
+		for _, i := range fields[1:] {
+			if b.fields[i].depth < minDepth {
+				minDepth = b.fields[i].depth
+			}
+		}
+		var winner = -1
+		for _, i := range fields {
+			if b.fields[i].depth > minDepth {
+				hidden[i] = true
+				continue
+			}
+			if winner >= 0 {
+				return nil, fmt.Errorf("%w: column %q matches fields %s and %s",
+					ErrAmbiguousColumn, b.header[idx], b.fields[winner].path, b.fields[i].path)
+			}
+			winner = i
+		}
+	}
+
+	m := &structMapping{}
+	bound := make([]bool, len(b.header))
+	for i, f := range b.fields {
+		if hidden[i] {
+			continue
+		}
+		for _, idx := range f.columns {
+			bound[idx] = true
+		}
+		m.fields = append(m.fields, f)
+	}
+
+	if b.rest != nil {
+		rest := *b.rest
+		rest.columns = []int{}
+		for i, ok := range bound {
+			if !ok {
+				rest.columns = append(rest.columns, i)
+			}
+		}
+		m.fields = append(m.fields, rest)
+		return m, nil
+	}
+
+	if !b.config.IgnoreUnmatchingFields {
+		for i, ok := range bound {
+			if !ok {
+				return nil, fmt.Errorf("%w: no field for column %q", ErrScanTargetsNotMatch, b.header[i])
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// isDecodable reports whether a value of type t is decoded as a whole,
+// by a registered conversion function or one of the decoder interfaces,
+// instead of being flattened.
+func (b *mappingBuilder) isDecodable(t reflect.Type) bool {
+	if b.config.converter("", t) != nil || isRecordDecoder(t) {
+		return true
+	}
+	st := t
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	if convertibleStructs[st] {
+		return true
+	}
+	for _, it := range []reflect.Type{interfaceType, contextInterfaceType} {
+		if t.Implements(it) || reflect.PtrTo(t).Implements(it) {
+			return true
+		}
+	}
+	return false
+}
+
+// decode copies the values in row into the struct dv, then checks the
+// validation rules of the fields.
+func (m *structMapping) decode(dv reflect.Value, row Row) error {
+	for _, f := range m.fields {
+		if err := f.decode(fieldByIndex(dv, f.index), row); err != nil {
+			return f.fieldError(row, err)
+		}
+	}
+	for _, f := range m.fields {
+		if len(f.rules) == 0 {
+			continue
+		}
+		if err := f.validate(f.value(row), fieldByIndex(dv, f.index), dv); err != nil {
+			return f.fieldError(row, err)
+		}
+	}
+	return nil
+}
+
+// fieldError positions the error err at the field in row.
+func (f fieldMapping) fieldError(row Row, err error) error {
+	var fieldErr *FieldError
+	if errors.As(err, &fieldErr) {
+		// the error is already positioned, e.g. by a nested mapping
+		return err
+	}
+	index := -1
+	if len(f.columns) == 1 && f.combine == nil && !f.rest && f.group == nil {
+		index = f.columns[0]
+	}
+	return &FieldError{
+		Row:    row.Number(),
+		Index:  index,
+		Column: f.name,
+		Err:    err,
+	}
+}
+
+// decode copies the values in row into the field fv.
+func (f fieldMapping) decode(fv reflect.Value, row Row) error {
+	switch {
+	case f.rest:
+		f.decodeRest(fv, row)
+		return nil
+	case f.group != nil:
+		return f.decodeGroups(fv, row)
+	case f.combine != nil:
+		values := make([]string, len(f.columns))
+		for i, idx := range f.columns {
+			values[i] = row.Index(idx)
+		}
+		return f.combine(fv.Addr().Interface(), values)
+	case f.record:
+		if f.columns != nil {
+			row = row.subset(f.columns)
+		}
+		return recordDecoder(fv).DecodeRecord(row)
+	default:
+		fc := f.fieldContext(row)
+		return convertAssignValue(fv.Addr().Interface(), f.value(row), &fc)
+	}
+}
+
+// decodeRest copies the columns of the rest field into fv. A map is
+// keyed by the name of the columns, a slice holds the values in order.
+func (f fieldMapping) decodeRest(fv reflect.Value, row Row) {
+	if fv.Kind() == reflect.Slice {
+		s := reflect.MakeSlice(fv.Type(), len(f.columns), len(f.columns))
+		for i, idx := range f.columns {
+			s.Index(i).SetString(row.Index(idx))
+		}
+		fv.Set(s)
+		return
+	}
+
+	if fv.IsNil() {
+		fv.Set(reflect.MakeMapWithSize(fv.Type(), len(f.columns)))
+	}
+	keyType, elemType := fv.Type().Key(), fv.Type().Elem()
+	for _, idx := range f.columns {
+		fv.SetMapIndex(
+			reflect.ValueOf(row.header[idx]).Convert(keyType),
+			reflect.ValueOf(row.Index(idx)).Convert(elemType),
+		)
+	}
+}
+
+// columnIndex returns the index of the column with the given name.
+// An exact match is preferred over a case insensitive one.
+// It returns -1 if there is no such column and an error if
+// several columns match equally.
+func columnIndex(header []string, name string) (int, error) {
+	for _, equal := range []func(a, b string) bool{
+		func(a, b string) bool { return a == b },
+		strings.EqualFold,
+	} {
+		found := -1
+		for i, h := range header {
+			if !equal(h, name) {
+				continue
+			}
+			if found >= 0 {
+				return -1, fmt.Errorf("%w: columns %q and %q both match %q", ErrAmbiguousColumn, header[found], h, name)
+			}
+			found = i
+		}
+		if found >= 0 {
+			return found, nil
+		}
+	}
+	return -1, nil
+}
+
+// fieldByIndex returns the nested field of v with the given index sequence,
+// allocating the embedded pointers on the way if needed.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// isRecordDecoder reports whether a value of type t can be decoded as a RecordDecoder.
+func isRecordDecoder(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr && t.Implements(recordDecoderType) {
+		return true
+	}
+	return reflect.PtrTo(t).Implements(recordDecoderType)
+}
+
+// recordDecoder returns the RecordDecoder of the field fv,
+// allocating it if fv is a nil pointer.
+func recordDecoder(fv reflect.Value) RecordDecoder {
+	if fv.Kind() == reflect.Ptr && fv.Type().Implements(recordDecoderType) {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		return fv.Interface().(RecordDecoder)
+	}
+	return fv.Addr().Interface().(RecordDecoder)
+}
@@ -0,0 +1,22 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// convertAssignTime parses src into dest with the layouts of the layout tag option,
+// separated by '|', or with the time layouts of the configuration.
+func convertAssignTime(dest *time.Time, src string, fc *FieldContext) error {
+	layouts := fc.Config.TimeLayouts
+	if layout, ok := fc.Options.Get("layout"); ok {
+		layouts = strings.Split(layout, "|")
+	}
+	t, ok := inferTime(src, layouts)
+	if !ok {
+		return fmt.Errorf("could not parse %q as a time", src)
+	}
+	*dest = t
+	return nil
+}
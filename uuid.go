@@ -0,0 +1,51 @@
+package csvdecoder
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// UUID is a universally unique identifier as defined by RFC 4122.
+// It can be used as a scan target or a struct field and is parsed
+// from its canonical form, e.g. 123e4567-e89b-12d3-a456-426614174000.
+type UUID [16]byte
+
+// ParseUUID returns the UUID in its canonical form in s: 32 hexadecimal
+// digits, in any case, grouped 8-4-4-4-12 and separated by hyphens.
+func ParseUUID(s string) (UUID, error) {
+	var u UUID
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		return u, fmt.Errorf("invalid UUID %q: expected the form xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx", s)
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:36]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return UUID{}, fmt.Errorf("invalid UUID %q: %w", s, err)
+	}
+	return u, nil
+}
+
+// String returns the canonical form of the UUID, in lower case.
+func (u UUID) String() string {
+	s := hex.EncodeToString(u[:])
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:32]
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (u *UUID) UnmarshalText(b []byte) error {
+	v, err := ParseUUID(string(b))
+	if err != nil {
+		return err
+	}
+	*u = v
+	return nil
+}
+
+// DecodeField implements the csvdecoder.Interface interface.
+func (u *UUID) DecodeField(s string) error {
+	return u.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,273 @@
+package csvdecoder
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// rule is a validation rule given in a `csv` struct tag, e.g. min=1.
+// It is checked after the fields of the struct are decoded.
+type rule struct {
+	name string
+	// check validates the field fv decoded from the CSV field raw.
+	// root is the struct being decoded, holding the fields compared by gt and lt.
+	check func(raw string, fv, root reflect.Value) error
+}
+
+// validationOptions are the tag options compiled into rules, in the order they are checked.
+var validationOptions = []string{"required", "len", "min", "max", "regex", "email", "gt", "lt"}
+
+// compileRules returns the validation rules of the field sf of the struct type t,
+// whose fields start with the index sequence index. The validation options are
+// reserved: a rule that doesn't compile is an error, even for a type implementing
+// ContextInterface. The other options are left to the decoders of the field.
+func compileRules(t reflect.Type, index []int, sf reflect.StructField, path string, options TagOptions) ([]rule, error) {
+	var rules []rule
+	for _, name := range validationOptions {
+		arg, ok := options.Get(name)
+		if !ok {
+			continue
+		}
+		check, err := compileRule(t, index, sf.Type, name, arg)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s: %s: %v", ErrInvalidTag, path, name, err)
+		}
+		rules = append(rules, rule{name: name, check: check})
+	}
+	return rules, nil
+}
+
+// compileRule returns the check of the rule name=arg for a field of type ft.
+func compileRule(t reflect.Type, index []int, ft reflect.Type, name, arg string) (func(string, reflect.Value, reflect.Value) error, error) {
+	switch name {
+	case "required":
+		return func(raw string, _, _ reflect.Value) error {
+			if raw == "" {
+				return fmt.Errorf("%w: a value is required", ErrValidation)
+			}
+			return nil
+		}, nil
+
+	case "len":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, fmt.Errorf("invalid length %q", arg)
+		}
+		if !hasLength(ft) {
+			return nil, fmt.Errorf("requires a string, slice, array or map, got %s", ft)
+		}
+		return func(_ string, fv, _ reflect.Value) error {
+			if l, ok := length(fv); ok && l != n {
+				return fmt.Errorf("%w: length %d is not %d", ErrValidation, l, n)
+			}
+			return nil
+		}, nil
+
+	case "min", "max":
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bound %q", arg)
+		}
+		if !hasLength(ft) && !isNumber(ft) {
+			return nil, fmt.Errorf("requires a number, string, slice, array or map, got %s", ft)
+		}
+		return func(_ string, fv, _ reflect.Value) error {
+			what, v, ok := "value", 0.0, false
+			if l, isLen := length(fv); isLen {
+				what, v, ok = "length", float64(l), true
+			} else {
+				v, ok = number(fv)
+			}
+			switch {
+			case !ok:
+				return nil
+			case name == "min" && v < bound:
+				return fmt.Errorf("%w: %s %v is less than the minimum %s", ErrValidation, what, v, arg)
+			case name == "max" && v > bound:
+				return fmt.Errorf("%w: %s %v is greater than the maximum %s", ErrValidation, what, v, arg)
+			}
+			return nil
+		}, nil
+
+	case "regex":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		return func(raw string, _, _ reflect.Value) error {
+			if !re.MatchString(raw) {
+				return fmt.Errorf("%w: %q does not match %s", ErrValidation, raw, arg)
+			}
+			return nil
+		}, nil
+
+	case "email":
+		return func(raw string, _, _ reflect.Value) error {
+			addr, err := mail.ParseAddress(raw)
+			if err != nil || addr.Name != "" || addr.Address != raw {
+				return fmt.Errorf("%w: %q is not an email address", ErrValidation, raw)
+			}
+			return nil
+		}, nil
+
+	case "gt", "lt":
+		other, ok := t.FieldByName(arg)
+		if !ok {
+			return nil, fmt.Errorf("no field %s in %s", arg, t)
+		}
+		if !isOrdered(ft) || !isOrdered(other.Type) || isNumber(ft) != isNumber(other.Type) || indirectType(ft) != indirectType(other.Type) && !isNumber(ft) {
+			return nil, fmt.Errorf("can't compare %s with field %s of type %s", ft, arg, other.Type)
+		}
+		otherIndex := append(index[:len(index):len(index)], other.Index...)
+		return func(_ string, fv, root reflect.Value) error {
+			c, ok := compare(fv, fieldByIndex(root, otherIndex))
+			switch {
+			case !ok:
+				return nil
+			case name == "gt" && c <= 0:
+				return fmt.Errorf("%w: value must be greater than field %s", ErrValidation, arg)
+			case name == "lt" && c >= 0:
+				return fmt.Errorf("%w: value must be less than field %s", ErrValidation, arg)
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown rule")
+}
+
+// validate checks the rules of the field fv decoded from raw. The rules other than
+// required are not checked for empty fields.
+func (f fieldMapping) validate(raw string, fv, root reflect.Value) error {
+	for _, r := range f.rules {
+		if raw == "" && r.name != "required" {
+			continue
+		}
+		if err := r.check(raw, fv, root); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// indirect returns the value pointed to by v. The boolean is false if a pointer is nil.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, true
+}
+
+func hasLength(t reflect.Type) bool {
+	switch indirectType(t).Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// length returns the length of v, in characters for a string.
+// The boolean is false if v has no length.
+func length(v reflect.Value) (int, bool) {
+	v, ok := indirect(v)
+	if !ok {
+		return 0, false
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	}
+	return 0, false
+}
+
+func isNumber(t reflect.Type) bool {
+	switch indirectType(t).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// number returns the value of the number v.
+// The boolean is false if v is not a number.
+func number(v reflect.Value) (float64, bool) {
+	v, ok := indirect(v)
+	if !ok {
+		return 0, false
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// isOrdered reports whether the values of type t can be compared by gt and lt.
+func isOrdered(t reflect.Type) bool {
+	it := indirectType(t)
+	return isNumber(it) || it.Kind() == reflect.String || it == timeType
+}
+
+// compare returns -1, 0 or 1 if a is less than, equal to or greater than b.
+// The boolean is false if a or b is a nil pointer.
+func compare(a, b reflect.Value) (int, bool) {
+	a, okA := indirect(a)
+	b, okB := indirect(b)
+	if !okA || !okB {
+		return 0, false
+	}
+
+	if a.Type() == timeType {
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1, true
+		case ta.After(tb):
+			return 1, true
+		}
+		return 0, true
+	}
+	if a.Kind() == reflect.String {
+		switch sa, sb := a.String(), b.String(); {
+		case sa < sb:
+			return -1, true
+		case sa > sb:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	na, _ := number(a)
+	nb, _ := number(b)
+	switch {
+	case na < nb:
+		return -1, true
+	case na > nb:
+		return 1, true
+	}
+	return 0, true
+}